@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -15,7 +16,9 @@ func main() {
 	}
 	defer db.Close()
 
-	user, err := account.GetUser(db, "123")
+	ctx := context.Background()
+
+	user, err := account.GetUser(ctx, db, "123")
 
 	if err != nil {
 		panic(err)
@@ -27,5 +30,5 @@ func main() {
 	user.UserID = "222"
 	user.UserName = "peter"
 
-	_, err = account.InsertUser(db, user)
+	_, err = account.InsertUser(ctx, db, user)
 }
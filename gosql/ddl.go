@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/YiCodes/gosql/sqlcodegen"
+)
+
+func runDDL(args []string) error {
+	flags := flag.NewFlagSet("ddl", flag.ExitOnError)
+
+	in := flags.String("in", ".", "source file or directory")
+	out := flags.String("out", "", "output file, default stdout")
+	dialectName := flags.String("dialect", "", "sql dialect: mysql, postgres, sqlite, sqlserver")
+
+	flags.Parse(args)
+
+	d, err := sqlcodegen.ParseDialect(*dialectName)
+
+	if err != nil {
+		return err
+	}
+
+	files, err := getSourceFiles(*in)
+
+	if err != nil {
+		return err
+	}
+
+	schema, err := sqlcodegen.LoadSchema(d, files...)
+
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+
+	if *out != "" {
+		f, err := os.Create(*out)
+
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+		w = f
+	}
+
+	return sqlcodegen.WriteDDL(w, schema, d)
+}
+
+func getSourceFiles(input string) ([]string, error) {
+	if !isDir(input) {
+		return []string{input}, nil
+	}
+
+	return sqlcodegen.GetModelFiles(input)
+}
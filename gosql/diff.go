@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// unifiedDiff 返回oldText与newText按行比较的unified格式差异，相同时返回空字符串
+func unifiedDiff(oldName string, newName string, oldText []byte, newText []byte) string {
+	a := splitLines(oldText)
+	b := splitLines(newText)
+	lines := diffLines(a, b)
+
+	var buffer bytes.Buffer
+
+	// 每个改动前后保留diffContext行，相隔较近的改动合并为一个hunk
+	for i := 0; i < len(lines); {
+		if lines[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := i - diffContext
+
+		if start < 0 {
+			start = 0
+		}
+
+		end := i
+
+		for end < len(lines) {
+			if lines[end].kind != ' ' {
+				end++
+				continue
+			}
+
+			next := end
+
+			for next < len(lines) && lines[next].kind == ' ' {
+				next++
+			}
+
+			if next == len(lines) || next-end > 2*diffContext {
+				end += diffContext
+
+				if end > len(lines) {
+					end = len(lines)
+				}
+
+				break
+			}
+
+			end = next
+		}
+
+		if buffer.Len() == 0 {
+			fmt.Fprintf(&buffer, "--- %s\n+++ %s\n", oldName, newName)
+		}
+
+		writeHunk(&buffer, lines, start, end)
+		i = end
+	}
+
+	return buffer.String()
+}
+
+func writeHunk(buffer *bytes.Buffer, lines []diffLine, start int, end int) {
+	var oldStart, newStart, oldCount, newCount int
+
+	for _, l := range lines[:start] {
+		if l.kind != '+' {
+			oldStart++
+		}
+
+		if l.kind != '-' {
+			newStart++
+		}
+	}
+
+	for _, l := range lines[start:end] {
+		if l.kind != '+' {
+			oldCount++
+		}
+
+		if l.kind != '-' {
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(buffer, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+
+	for _, l := range lines[start:end] {
+		buffer.WriteByte(l.kind)
+		buffer.WriteString(l.text)
+		buffer.WriteByte('\n')
+	}
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	if count == 1 {
+		return fmt.Sprint(start + 1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines 按行拆分text，最后一行没有换行符时与diff相同加上标记，使其与带换行符的行不相等
+func splitLines(text []byte) []string {
+	if len(text) == 0 {
+		return nil
+	}
+
+	lines := strings.Split(string(text), "\n")
+
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	last := len(lines) - 1
+
+	if lines[last] == "" {
+		return lines[:last]
+	}
+
+	lines[last] += "\n\\ No newline at end of file"
+
+	return lines
+}
+
+// diffLines 使用Myers算法计算最短编辑序列
+func diffLines(a []string, b []string) []diffLine {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	var trace [][]int
+
+Search:
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				break Search
+			}
+		}
+	}
+
+	var reversed []diffLine
+
+	x, y := n, m
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		// trace[d]保存第d步之前的状态，下标k对应trace[d][k+d]
+		prev := trace[d]
+		get := func(k int) int {
+			if k < -d || k > d {
+				return 0
+			}
+
+			return prev[k+d]
+		}
+
+		k := x - y
+
+		var prevK int
+
+		if k == -d || (k != d && get(k-1) < get(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := get(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, diffLine{' ', a[x]})
+		}
+
+		if d > 0 {
+			if x == prevX {
+				y--
+				reversed = append(reversed, diffLine{'+', b[y]})
+			} else {
+				x--
+				reversed = append(reversed, diffLine{'-', a[x]})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	lines := make([]diffLine, len(reversed))
+
+	for i, l := range reversed {
+		lines[len(reversed)-1-i] = l
+	}
+
+	return lines
+}
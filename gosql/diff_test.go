@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// numberLines 返回1到n每行一个数字的文本，replace替换其中的行
+func numberLines(n int, replace map[int]string) string {
+	var buffer strings.Builder
+
+	for i := 1; i <= n; i++ {
+		if s, ok := replace[i]; ok {
+			buffer.WriteString(s + "\n")
+		} else {
+			buffer.WriteString(strconv.Itoa(i) + "\n")
+		}
+	}
+
+	return buffer.String()
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"same", "a\nb\n", "a\nb\n", ""},
+		{"empty", "", "", ""},
+		{"line endings", "a\r\nb\r\n", "a\nb\n", ""},
+		{
+			"change", numberLines(10, nil), numberLines(10, map[int]string{5: "five"}),
+			"@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			"separate hunks", numberLines(20, nil), numberLines(20, map[int]string{2: "two", 18: "eighteen"}),
+			"@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n" +
+				"@@ -15,6 +15,6 @@\n 15\n 16\n 17\n-18\n+eighteen\n 19\n 20\n",
+		},
+		{
+			"merged hunks", numberLines(12, nil), numberLines(12, map[int]string{3: "three", 8: "eight"}),
+			"@@ -1,11 +1,11 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n 7\n-8\n+eight\n 9\n 10\n 11\n",
+		},
+		{"insert only", "a\nb\nc\nd\ne\nf\ng\nh\n", "a\nb\nc\nd\nX\ne\nf\ng\nh\n", "@@ -2,6 +2,7 @@\n b\n c\n d\n+X\n e\n f\n g\n"},
+		{"delete only", "a\nb\nc\nd\nX\ne\nf\ng\nh\n", "a\nb\nc\nd\ne\nf\ng\nh\n", "@@ -2,7 +2,6 @@\n b\n c\n d\n-X\n e\n f\n g\n"},
+		{"new file", "", "a\nb\n", "@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"removed file", "a\nb\n", "", "@@ -1,2 +0,0 @@\n-a\n-b\n"},
+		{"no newline in new", "a\nb\n", "a\nb", "@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n"},
+		{"no newline in old", "a\nb", "a\nb\n", "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"},
+	}
+
+	for _, test := range tests {
+		want := test.want
+
+		if want != "" {
+			want = "--- old.go\n+++ new.go\n" + want
+		}
+
+		if got := unifiedDiff("old.go", "new.go", []byte(test.old), []byte(test.new)); got != want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build mysql
+
+package main
+
+import (
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/YiCodes/gosql/sqlcodegen"
+)
+
+func init() {
+	drivers[sqlcodegen.DialectMySQL] = "mysql"
+}
@@ -0,0 +1,13 @@
+//go:build postgres
+
+package main
+
+import (
+	_ "github.com/lib/pq"
+
+	"github.com/YiCodes/gosql/sqlcodegen"
+)
+
+func init() {
+	drivers[sqlcodegen.DialectPostgres] = "postgres"
+}
@@ -0,0 +1,13 @@
+//go:build sqlite
+
+package main
+
+import (
+	_ "modernc.org/sqlite"
+
+	"github.com/YiCodes/gosql/sqlcodegen"
+)
+
+func init() {
+	drivers[sqlcodegen.DialectSQLite] = "sqlite"
+}
@@ -0,0 +1,13 @@
+//go:build sqlserver
+
+package main
+
+import (
+	_ "github.com/microsoft/go-mssqldb"
+
+	"github.com/YiCodes/gosql/sqlcodegen"
+)
+
+func init() {
+	drivers[sqlcodegen.DialectSQLServer] = "sqlserver"
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/YiCodes/gosql/sqlcodegen"
+)
+
+func runIntrospect(args []string) error {
+	flags := flag.NewFlagSet("introspect", flag.ExitOnError)
+
+	dialectName := flags.String("dialect", "", "sql dialect: mysql, postgres, sqlite, sqlserver")
+	dsn := flags.String("dsn", "", "data source name")
+	out := flags.String("out", "models", "output directory")
+	packageName := flags.String("pkg", "", "package name, default the name of output directory")
+	tables := flags.String("tables", "", "comma separated table names, default all tables")
+	crud := flags.Bool("crud", false, "generate Get, Insert, Update and Delete functions by primary key")
+
+	flags.Parse(args)
+
+	d, db, err := openDB(*dialectName, *dsn)
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	schema, err := sqlcodegen.ReadSchema(context.Background(), db, d)
+
+	if err != nil {
+		return err
+	}
+
+	opts := sqlcodegen.ModelOptions{PackageName: *packageName, CRUD: *crud}
+
+	if opts.PackageName == "" {
+		dir, err := filepath.Abs(*out)
+
+		if err != nil {
+			return err
+		}
+
+		opts.PackageName = strings.ToLower(filepath.Base(dir))
+	}
+
+	filter := make(map[string]bool)
+
+	for _, name := range strings.Split(*tables, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			filter[name] = true
+		}
+	}
+
+	if err := makeDir(*out); err != nil {
+		return err
+	}
+
+	for _, t := range schema.Tables {
+		if len(filter) > 0 && !filter[t.Name] {
+			continue
+		}
+
+		var buffer bytes.Buffer
+
+		if err := sqlcodegen.WriteModel(&buffer, t, d, opts); err != nil {
+			return err
+		}
+
+		typeName := sqlcodegen.GetModelTypeName(t.Name)
+		dest := filepath.Join(*out, strings.ToLower(typeName[:1])+typeName[1:]+".go")
+
+		if err := ioutil.WriteFile(dest, buffer.Bytes(), 0666); err != nil {
+			return err
+		}
+
+		fmt.Printf("%v: %v\n", t.Name, dest)
+	}
+
+	fmt.Println("complete.")
+
+	return nil
+}
@@ -1,22 +1,34 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/YiCodes/gosql/sqlcodegen"
 )
 
 var (
 	input, output string
+	dialect       string
+	noContext     bool
+	singleFile    string
+	check         bool
+	watch         bool
 )
 
 func init() {
-	flag.StringVar(&input, "in", ".", "source file or directory")
+	flag.StringVar(&input, "in", ".", "source file, directory or pattern like ./...")
 	flag.StringVar(&output, "out", "", "output directory")
+	flag.StringVar(&dialect, "dialect", "", "sql dialect: mysql, postgres, sqlite, sqlserver")
+	flag.BoolVar(&noContext, "nocontext", false, "generate functions without context.Context parameter")
+	flag.StringVar(&singleFile, "single", "", "generate all description files of the package into this file")
+	flag.BoolVar(&watch, "watch", false, "watch description files and regenerate the packages that changed")
+	flag.BoolVar(&check, "check", false, "check that generated files are up to date without writing them, print a diff otherwise")
 }
 
 func makeDir(dir string) error {
@@ -24,11 +36,11 @@ func makeDir(dir string) error {
 
 	if err != nil {
 		if os.IsNotExist(err) {
-			err = os.Mkdir(dir, os.ModePerm)
+			err = os.MkdirAll(dir, os.ModePerm)
 		}
 	} else {
 		if !f.IsDir() {
-			return fmt.Errorf("%v is a file", output)
+			return fmt.Errorf("%v is a file", dir)
 		}
 	}
 
@@ -45,96 +57,232 @@ func isDir(path string) bool {
 	return false
 }
 
-func genCode() error {
+func genCode(args []string) error {
 	var err error
 
-	if !filepath.IsAbs(input) {
-		input, err = filepath.Abs(input)
+	opts := sqlcodegen.Options{NoContext: noContext, SingleFile: singleFile}
+	opts.Warn = func(d *sqlcodegen.Diagnostic) { fmt.Println(d) }
+	opts.Dialect, err = sqlcodegen.ParseDialect(dialect)
 
-		if err != nil {
-			return err
-		}
+	if err != nil {
+		return err
+	}
+
+	patterns := args
+
+	if len(patterns) == 0 {
+		patterns = []string{input}
 	}
 
-	fmt.Printf("in: %v\n", input)
+	inputs, err := expandPatterns(patterns)
 
-	if output == "" {
-		var d string
+	if err != nil {
+		return err
+	}
 
-		if isDir(input) {
-			d = input
-		} else {
-			d = filepath.Dir(input)
+	if len(inputs) == 0 {
+		return fmt.Errorf("no packages import %v in %v", sqlcodegenPath, strings.Join(patterns, " "))
+	}
+
+	// 生成多个包时out为相对于每个包的目录，监视./...时之后可能新增包，同样如此
+	multiple := len(inputs) > 1 || watch && hasWildcard(patterns)
+
+	if multiple && filepath.IsAbs(output) {
+		return fmt.Errorf("-out must be a relative path when generating %v", strings.Join(patterns, " "))
+	}
+
+	if watch {
+		if check {
+			return fmt.Errorf("-watch and -check can not be used together")
 		}
 
-		output = filepath.Join(d, "gen")
+		return watchInputs(patterns, inputs, multiple, opts)
 	}
 
-	fmt.Printf("out: %v\n", output)
+	var failed int
 
-	if !filepath.IsAbs(output) {
-		output, _ = filepath.Abs(output)
+	for _, in := range inputs {
+		if err := genInput(in, multiple, opts); err != nil {
+			fmt.Println(err)
+			failed++
+		}
 	}
 
-	err = makeDir(output)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d input(s) failed", failed, len(inputs))
+	}
+
+	fmt.Println("complete.")
+
+	return nil
+}
+
+func genInput(in string, multiple bool, opts sqlcodegen.Options) error {
+	inputInfo, err := os.Stat(in)
 
 	if err != nil {
 		return err
 	}
 
-	inputInfo, err := os.Stat(input)
+	dir := in
 
-	if err != nil {
+	if !inputInfo.IsDir() {
+		if !strings.HasSuffix(in, ".go") {
+			return fmt.Errorf("%v is not a go source file", in)
+		}
+
+		dir = filepath.Dir(in)
+	}
+
+	out := output
+
+	if out == "" {
+		out = filepath.Join(dir, "gen")
+	} else if multiple {
+		out = filepath.Join(dir, out)
+	} else if out, err = filepath.Abs(out); err != nil {
 		return err
 	}
 
+	fmt.Printf("in: %v\n", in)
+	fmt.Printf("out: %v\n", out)
+
+	var files []*sqlcodegen.GeneratedFile
+
 	if inputInfo.IsDir() {
-		files, err := ioutil.ReadDir(input)
+		files, err = sqlcodegen.GeneratePackage(in, opts)
+	} else {
+		var buffer bytes.Buffer
 
-		if err != nil {
+		err = sqlcodegen.CompileTo(in, &buffer, opts)
+		files = []*sqlcodegen.GeneratedFile{{Name: filepath.Base(in), Content: buffer.Bytes()}}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if check {
+		return checkFiles(out, files, inputInfo.IsDir())
+	}
+
+	if err := makeDir(out); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if err := ioutil.WriteFile(filepath.Join(out, f.Name), f.Content, 0666); err != nil {
 			return err
 		}
+	}
 
-		for _, f := range files {
-			if f.IsDir() {
-				continue
-			}
+	return nil
+}
 
-			src := filepath.Join(input, f.Name())
-			dest := filepath.Join(output, f.Name())
+// checkFiles 比较生成的代码与out中已有的文件，不一致时输出unified diff。
+// wholePackage为true时out中不会再生成的gosql文件（如描述文件已删除）同样视为过期
+func checkFiles(out string, files []*sqlcodegen.GeneratedFile, wholePackage bool) error {
+	var stale int
 
-			err = sqlcodegen.Compile(src, dest, sqlcodegen.Options{})
+	if wholePackage {
+		list, err := getStaleFiles(out, files)
 
-			if err != nil {
-				os.Remove(dest)
+		if err != nil {
+			return err
+		}
 
-				return err
-			}
+		for _, fileName := range list {
+			fmt.Printf("%v: no description file generates this file any more, delete it\n", fileName)
 		}
-	} else {
-		_, fileName := filepath.Split(input)
-		dest := filepath.Join(output, fileName)
 
-		err = sqlcodegen.Compile(input, dest, sqlcodegen.Options{})
+		stale += len(list)
+	}
 
-		if err != nil {
-			os.Remove(dest)
+	for _, f := range files {
+		fileName := filepath.Join(out, f.Name)
+		content, err := ioutil.ReadFile(fileName)
 
+		if err != nil && !os.IsNotExist(err) {
 			return err
 		}
+
+		if bytes.Equal(content, f.Content) {
+			continue
+		}
+
+		stale++
+
+		if diff := unifiedDiff(fileName, fileName+" (generated)", content, f.Content); diff != "" {
+			fmt.Print(diff)
+		} else {
+			fmt.Printf("%v: line endings differ\n", fileName)
+		}
 	}
 
-	fmt.Println("complete.")
+	if stale > 0 {
+		return fmt.Errorf("%d file(s) in %v are out of date, run gosql to regenerate", stale, out)
+	}
 
 	return nil
 }
 
+// getStaleFiles 返回out中带有gosql生成代码头部、但不在files中的.go文件
+func getStaleFiles(out string, files []*sqlcodegen.GeneratedFile) ([]string, error) {
+	entries, err := ioutil.ReadDir(out)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	expected := make(map[string]bool)
+
+	for _, f := range files {
+		expected[f.Name] = true
+	}
+
+	var result []string
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || expected[entry.Name()] {
+			continue
+		}
+
+		fileName := filepath.Join(out, entry.Name())
+		content, err := ioutil.ReadFile(fileName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if sqlcodegen.IsGeneratedCode(content) {
+			result = append(result, fileName)
+		}
+	}
+
+	return result, nil
+}
+
 func main() {
-	flag.Parse()
+	var err error
 
-	err := genCode()
+	if len(os.Args) > 1 && os.Args[1] == "ddl" {
+		err = runDDL(os.Args[2:])
+	} else if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrate(os.Args[2:])
+	} else if len(os.Args) > 1 && os.Args[1] == "introspect" {
+		err = runIntrospect(os.Args[2:])
+	} else {
+		flag.Parse()
+
+		err = genCode(flag.Args())
+	}
 
 	if err != nil {
 		fmt.Println(err)
+		os.Exit(1)
 	}
 }
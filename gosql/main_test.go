@@ -0,0 +1,116 @@
+package main
+
+import (
+	"go/build"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YiCodes/gosql/sqlcodegen"
+)
+
+func TestCheckFilesStale(t *testing.T) {
+	out := t.TempDir()
+	current := []byte("// Code generated by gosql. DO NOT EDIT.\n\npackage account\n")
+
+	files := map[string][]byte{
+		"account.go": current,
+		"removed.go": []byte("// Code generated by gosql. DO NOT EDIT.\n\npackage account\n"),
+		"helper.go":  []byte("package account\n"),
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(out, name), content, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	generated := []*sqlcodegen.GeneratedFile{{Name: "account.go", Content: current}}
+
+	err := checkFiles(out, generated, true)
+
+	if err == nil || !strings.HasPrefix(err.Error(), "1 file(s)") {
+		t.Errorf("checkFiles with a stale file: %v, want 1 out of date file", err)
+	}
+
+	stale, err := getStaleFiles(out, generated)
+
+	if err != nil || len(stale) != 1 || filepath.Base(stale[0]) != "removed.go" {
+		t.Errorf("getStaleFiles = %v, %v, want removed.go", stale, err)
+	}
+
+	// 只生成一个描述文件时，其他生成的文件不属于这次检查
+	if err := checkFiles(out, generated, false); err != nil {
+		t.Errorf("checkFiles of a single file: %v", err)
+	}
+}
+
+func TestCheckFilesMissingOutput(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "gen")
+	generated := []*sqlcodegen.GeneratedFile{{Name: "account.go", Content: []byte("package account\n")}}
+
+	if err := checkFiles(out, generated, true); err == nil {
+		t.Error("checkFiles without output directory succeeded")
+	}
+}
+
+func TestWatchDirs(t *testing.T) {
+	gopath := t.TempDir()
+	old := build.Default.GOPATH
+
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("GO111MODULE", "off")
+	build.Default.GOPATH = gopath
+	t.Cleanup(func() { build.Default.GOPATH = old })
+
+	src := filepath.Join(gopath, "src")
+	descDir := filepath.Join(src, "app", "desc")
+	modelDir := filepath.Join(src, "app", "models")
+
+	writeTestFile(t, filepath.Join(modelDir, "models.go"), "package models\n\ntype User struct {\n\tUserID int64\n}\n")
+	writeTestFile(t, filepath.Join(descDir, "desc.go"), `package desc
+
+import (
+	"database/sql"
+
+	"app/models"
+	"github.com/YiCodes/gosql/sqlcodegen"
+)
+
+var _ sql.NullString
+var user models.User
+
+func GetUser(userID int64) {
+	sqlcodegen.From(user)
+	sqlcodegen.SelectAll(user)
+	sqlcodegen.Where(user.UserID == userID)
+}
+`)
+
+	w := newWatchedInput(descDir)
+
+	if len(w.dirs) != 2 || w.dirs[0] != descDir || w.dirs[1] != modelDir {
+		t.Fatalf("watched directories %v, want %v and %v", w.dirs, descDir, modelDir)
+	}
+
+	// 修改其他目录中的模型包同样需要重新生成
+	writeTestFile(t, filepath.Join(modelDir, "models.go"), "package models\n\ntype User struct {\n\tUserID   int64\n\tUserName string\n}\n")
+
+	if sameState(snapshot(w.dirs), w.state) {
+		t.Error("change of the model package is not detected")
+	}
+
+	// 之后新增的包在下一次展开./...时出现
+	inputs, err := expandPatterns([]string{filepath.Join(src, "app") + "/..."})
+
+	if err != nil || len(inputs) != 1 {
+		t.Fatalf("expandPatterns = %v, %v, want the desc package", inputs, err)
+	}
+
+	writeTestFile(t, filepath.Join(src, "app", "orders", "orders.go"), "package orders\n\nimport \"github.com/YiCodes/gosql/sqlcodegen\"\n\nfunc F() { sqlcodegen.Transactional() }\n")
+
+	if inputs, err = expandPatterns([]string{filepath.Join(src, "app") + "/..."}); err != nil || len(inputs) != 2 {
+		t.Errorf("expandPatterns after adding a package = %v, %v, want 2 packages", inputs, err)
+	}
+}
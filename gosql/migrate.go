@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/YiCodes/gosql/sqlcodegen"
+	"github.com/YiCodes/gosql/sqlutil"
+)
+
+// drivers 方言对应的database/sql驱动名称，由driver_*.go按编译标签注册
+var drivers = make(map[sqlcodegen.Dialect]string)
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: gosql migrate diff|up [flags]")
+	}
+
+	switch args[0] {
+	case "diff":
+		return runMigrateDiff(args[1:])
+	case "up":
+		return runMigrateUp(args[1:])
+	}
+
+	return fmt.Errorf("unknown migrate command %q", args[0])
+}
+
+func runMigrateDiff(args []string) error {
+	flags := flag.NewFlagSet("migrate diff", flag.ExitOnError)
+
+	in := flags.String("in", ".", "source file or directory")
+	dialectName := flags.String("dialect", "", "sql dialect: mysql, postgres, sqlite, sqlserver")
+	dsn := flags.String("dsn", "", "data source name")
+	dir := flags.String("dir", "migrations", "migrations directory")
+	name := flags.String("name", "migration", "migration name")
+
+	flags.Parse(args)
+
+	d, db, err := openDB(*dialectName, *dsn)
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	files, err := getSourceFiles(*in)
+
+	if err != nil {
+		return err
+	}
+
+	target, err := sqlcodegen.LoadSchema(d, files...)
+
+	if err != nil {
+		return err
+	}
+
+	current, err := sqlcodegen.ReadSchema(context.Background(), db, d)
+
+	if err != nil {
+		return err
+	}
+
+	up, down, err := sqlcodegen.DiffSchema(current, target, d)
+
+	if err != nil {
+		return err
+	}
+
+	if up == "" {
+		fmt.Println("no changes.")
+
+		return nil
+	}
+
+	if err := makeDir(*dir); err != nil {
+		return err
+	}
+
+	prefix := filepath.Join(*dir, time.Now().Format("20060102150405")+"_"+*name)
+
+	if err := ioutil.WriteFile(prefix+".up.sql", []byte(up), 0666); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(prefix+".down.sql", []byte(down), 0666); err != nil {
+		return err
+	}
+
+	fmt.Printf("up: %v.up.sql\n", prefix)
+	fmt.Printf("down: %v.down.sql\n", prefix)
+
+	return nil
+}
+
+func runMigrateUp(args []string) error {
+	flags := flag.NewFlagSet("migrate up", flag.ExitOnError)
+
+	dialectName := flags.String("dialect", "", "sql dialect: mysql, postgres, sqlite, sqlserver")
+	dsn := flags.String("dsn", "", "data source name")
+	dir := flags.String("dir", "migrations", "migrations directory")
+
+	flags.Parse(args)
+
+	d, db, err := openDB(*dialectName, *dsn)
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	versions, err := sqlutil.MigrateUp(context.Background(), db, getBindType(d), *dir)
+
+	for _, v := range versions {
+		fmt.Printf("applied: %v\n", v)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("complete.")
+
+	return nil
+}
+
+func openDB(dialectName string, dsn string) (sqlcodegen.Dialect, *sql.DB, error) {
+	d, err := sqlcodegen.ParseDialect(dialectName)
+
+	if err != nil {
+		return d, nil, err
+	}
+
+	if d == sqlcodegen.DialectDefault {
+		return d, nil, errors.New("migrate requires -dialect")
+	}
+
+	driver, ok := drivers[d]
+
+	if !ok {
+		return d, nil, fmt.Errorf("driver for %v is not compiled in, rebuild gosql with -tags %v", d, d)
+	}
+
+	db, err := sql.Open(driver, dsn)
+
+	return d, db, err
+}
+
+func getBindType(d sqlcodegen.Dialect) sqlutil.BindType {
+	switch d {
+	case sqlcodegen.DialectPostgres:
+		return sqlutil.BindDollar
+	case sqlcodegen.DialectSQLServer:
+		return sqlutil.BindAt
+	}
+
+	return sqlutil.BindQuestion
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/YiCodes/gosql/sqlcodegen"
+)
+
+const sqlcodegenPath = "github.com/YiCodes/gosql/sqlcodegen"
+
+// expandPatterns 依次展开每个输入
+func expandPatterns(patterns []string) ([]string, error) {
+	var inputs []string
+
+	for _, pattern := range patterns {
+		list, err := expandPattern(pattern)
+
+		if err != nil {
+			return nil, err
+		}
+
+		inputs = append(inputs, list...)
+	}
+
+	return inputs, nil
+}
+
+func hasWildcard(patterns []string) bool {
+	for _, pattern := range patterns {
+		if pattern == "..." || strings.HasSuffix(pattern, "/...") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expandPattern 将输入转换为绝对路径；以/...结尾时返回目录下所有导入sqlcodegen的包，
+// 与go命令相同，忽略以.或_开头的目录以及testdata、vendor目录
+func expandPattern(pattern string) ([]string, error) {
+	if !hasWildcard([]string{pattern}) {
+		path, err := filepath.Abs(pattern)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{path}, nil
+	}
+
+	root := strings.TrimSuffix(pattern, "...")
+
+	if root == "" {
+		root = "."
+	}
+
+	root, err := filepath.Abs(root)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		name := info.Name()
+
+		if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor") {
+			return filepath.SkipDir
+		}
+
+		ok, err := importsSqlcodegen(path)
+
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			result = append(result, path)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// importsSqlcodegen 判断目录中是否有导入sqlcodegen的描述文件
+func importsSqlcodegen(dir string) (bool, error) {
+	fileNames, err := sqlcodegen.GetModelFiles(dir)
+
+	if err != nil {
+		return false, err
+	}
+
+	fset := token.NewFileSet()
+
+	for _, fileName := range fileNames {
+		file, err := parser.ParseFile(fset, fileName, nil, parser.ImportsOnly)
+
+		if file == nil {
+			return false, err
+		}
+
+		for _, spec := range file.Imports {
+			if path, _ := strconv.Unquote(spec.Path.Value); path == sqlcodegenPath {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, fileName string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(fileName), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(fileName, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpandPattern(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desc := "package p\n\nimport \"github.com/YiCodes/gosql/sqlcodegen\"\n\nvar _ = sqlcodegen.From\n"
+
+	for _, dir := range []string{"", "account", "account/orders", ".hidden", "_tmp", "testdata", "vendor/lib"} {
+		writeTestFile(t, filepath.Join(root, dir, "desc.go"), desc)
+	}
+
+	writeTestFile(t, filepath.Join(root, "plain", "plain.go"), "package plain\n")
+	// 生成的代码不是描述文件
+	writeTestFile(t, filepath.Join(root, "gen", "gen.go"), "// Code generated by gosql. DO NOT EDIT.\n\n"+desc)
+
+	wd, err := os.Getwd()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"./...", []string{"", "account", "account/orders"}},
+		{"...", []string{"", "account", "account/orders"}},
+		{"account/...", []string{"account", "account/orders"}},
+		{root + "/account/orders/...", []string{"account/orders"}},
+		{"./plain/...", nil},
+		// 不带/...的输入原样返回，不检查是否导入sqlcodegen
+		{"plain", []string{"plain"}},
+		{"testdata", []string{"testdata"}},
+	}
+
+	for _, test := range tests {
+		inputs, err := expandPattern(test.pattern)
+
+		if err != nil {
+			t.Errorf("%s: %v", test.pattern, err)
+			continue
+		}
+
+		var got []string
+
+		for _, input := range inputs {
+			rel, err := filepath.Rel(root, input)
+
+			if err != nil || !filepath.IsAbs(input) {
+				t.Errorf("%s: %s is not an absolute path under %s", test.pattern, input, root)
+			}
+
+			if rel == "." {
+				rel = ""
+			}
+
+			got = append(got, filepath.ToSlash(rel))
+		}
+
+		if strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("expandPattern(%q) = %q, want %q", test.pattern, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YiCodes/gosql/sqlcodegen"
+)
+
+const (
+	pollInterval  = 500 * time.Millisecond
+	debounceDelay = 300 * time.Millisecond
+)
+
+// watchedInput 监视中的输入，dirs为描述文件所在的目录以及导入的模型包所在的目录
+type watchedInput struct {
+	dirs  []string
+	state map[string]string
+}
+
+func newWatchedInput(in string) *watchedInput {
+	w := &watchedInput{dirs: getWatchDirs(in)}
+	w.state = snapshot(w.dirs)
+
+	return w
+}
+
+// watchInputs 轮询描述文件与导入的模型包的修改时间，文件变化后等待debounceDelay内没有新的修改再重新生成所在的包；
+// 每次轮询重新展开patterns，之后新增的包会立即生成，删除的包不再监视。生成失败时保留上一次生成的文件
+func watchInputs(patterns []string, inputs []string, multiple bool, opts sqlcodegen.Options) error {
+	watched := make(map[string]*watchedInput)
+
+	for _, in := range inputs {
+		regenerate(in, multiple, opts)
+		watched[in] = newWatchedInput(in)
+	}
+
+	fmt.Printf("watching %d input(s), press Ctrl+C to stop\n", len(inputs))
+
+	pending := make(map[string]time.Time)
+
+	for {
+		time.Sleep(pollInterval)
+
+		inputs, err := expandPatterns(patterns)
+
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		current := make(map[string]bool)
+
+		for _, in := range inputs {
+			current[in] = true
+			w, ok := watched[in]
+
+			if !ok {
+				regenerate(in, multiple, opts)
+				watched[in] = newWatchedInput(in)
+				continue
+			}
+
+			if state := snapshot(w.dirs); !sameState(state, w.state) {
+				w.state = state
+				pending[in] = time.Now()
+			}
+		}
+
+		for in := range watched {
+			if !current[in] {
+				delete(watched, in)
+				delete(pending, in)
+			}
+		}
+
+		for in, changed := range pending {
+			if time.Since(changed) < debounceDelay {
+				continue
+			}
+
+			delete(pending, in)
+			regenerate(in, multiple, opts)
+
+			// 输出目录可能与描述文件相同，导入的包也可能改变，生成后重新记录状态
+			watched[in] = newWatchedInput(in)
+		}
+	}
+}
+
+func regenerate(in string, multiple bool, opts sqlcodegen.Options) {
+	fmt.Printf("[%s] ", time.Now().Format("15:04:05"))
+
+	if err := genInput(in, multiple, opts); err != nil {
+		fmt.Println(err)
+		fmt.Println("generation failed, previous output kept")
+		return
+	}
+
+	fmt.Println("complete.")
+}
+
+// getWatchDirs 返回输入所在的目录，以及描述文件导入的、不在GOROOT中的包（如其他目录中的模型包）所在的目录
+func getWatchDirs(in string) []string {
+	dir := in
+	fileNames := []string{in}
+
+	if isDir(in) {
+		fileNames, _ = sqlcodegen.GetModelFiles(in)
+	} else {
+		dir = filepath.Dir(in)
+	}
+
+	dirs := []string{dir}
+	found := map[string]bool{dir: true}
+	fset := token.NewFileSet()
+
+	for _, fileName := range fileNames {
+		file, err := parser.ParseFile(fset, fileName, nil, parser.ImportsOnly)
+
+		if file == nil {
+			fmt.Println(err)
+			continue
+		}
+
+		for _, spec := range file.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+
+			if path == sqlcodegenPath || path == "C" {
+				continue
+			}
+
+			pkg, err := build.Import(path, dir, build.FindOnly)
+
+			if err != nil || pkg.Goroot || found[pkg.Dir] {
+				continue
+			}
+
+			found[pkg.Dir] = true
+			dirs = append(dirs, pkg.Dir)
+		}
+	}
+
+	return dirs
+}
+
+// snapshot 记录dirs中.go文件的修改时间与大小
+func snapshot(dirs []string) map[string]string {
+	state := make(map[string]string)
+
+	for _, dir := range dirs {
+		entries, err := ioutil.ReadDir(dir)
+
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") && !strings.HasSuffix(entry.Name(), "_test.go") {
+				state[filepath.Join(dir, entry.Name())] = fmt.Sprint(entry.ModTime().UnixNano(), entry.Size())
+			}
+		}
+	}
+
+	return state
+}
+
+func sameState(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for name, value := range a {
+		if b[name] != value {
+			return false
+		}
+	}
+
+	return true
+}
@@ -3,8 +3,10 @@ package sqlcodegen
 import (
 	"fmt"
 	"io"
+	"strconv"
 
 	"go/ast"
+	"go/types"
 )
 
 type codeGenerator struct {
@@ -22,7 +24,7 @@ type generatorSettings struct {
 func newGenerator() *codeGenerator {
 	g := &codeGenerator{}
 	g.settings.indent = "\t"
-	g.settings.line = "\r\n"
+	g.settings.line = "\n"
 	g.newLine = true
 
 	return g
@@ -84,6 +86,8 @@ func (g *codeGenerator) writeExpr(expr ast.Expr) {
 		g.writeExpr(inst.X)
 		g.write(".")
 		g.write(inst.Sel.Name)
+	default:
+		g.write(types.ExprString(expr))
 	}
 }
 
@@ -114,8 +118,15 @@ func (g *codeGenerator) writeFuncType(list []*ast.Field, hasParenthesis bool) {
 			g.write(", ")
 		}
 
+		for j, name := range field.Names {
+			if j > 0 {
+				g.write(", ")
+			}
+
+			g.write(name.Name)
+		}
+
 		if len(field.Names) > 0 {
-			g.write(field.Names[0].Name)
 			g.write(" ")
 		}
 		g.writeExpr(field.Type)
@@ -162,7 +173,7 @@ func (g *codeGenerator) writeConstDeclaration(varName string, initValue interfac
 
 	switch inst := initValue.(type) {
 	case string:
-		g.writeStringValue(inst)
+		g.write(strconv.Quote(inst))
 	default:
 		g.write(fmt.Sprint(inst))
 	}
@@ -0,0 +1,379 @@
+package sqlcodegen
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type ddlDialect interface {
+	sqlDialect
+	// columnType 返回Go基础类型对应的列类型，不支持的类型返回空字符串
+	columnType(baseType string, size int) string
+	// identityClause 返回自增列的定义，consumed为true时主键已在列定义中声明
+	identityClause(isPrimaryKey bool) (clause string, consumed bool)
+	// identityNeedsKey 为true时自增列必须是某个索引的第一列，且该索引需要在CREATE TABLE中声明
+	identityNeedsKey() bool
+}
+
+func getDDLDialect(dialect Dialect) (ddlDialect, error) {
+	d, err := getSQLDialect(dialect)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return d.(ddlDialect), nil
+}
+
+// WriteDDL 按照方言输出创建数据库结构的CREATE TABLE、CREATE INDEX语句
+func WriteDDL(w io.Writer, schema *Schema, dialect Dialect) error {
+	d, err := getDDLDialect(dialect)
+
+	if err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+
+	for i, t := range schema.Tables {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+
+		writeCreateTable(&buffer, d, t)
+		writeCreateIndexes(&buffer, d, t)
+	}
+
+	_, err = w.Write(buffer.Bytes())
+
+	return err
+}
+
+func writeCreateTable(buffer *bytes.Buffer, d ddlDialect, t *TableSchema) {
+	buffer.WriteString("CREATE TABLE ")
+	buffer.WriteString(quoteName(d, t.Name))
+	buffer.WriteString(" (\n")
+
+	var primaryKeyConsumed bool
+
+	for i, c := range t.Columns {
+		if i > 0 {
+			buffer.WriteString(",\n")
+		}
+
+		isPrimaryKey := len(t.PrimaryKey) == 1 && t.PrimaryKey[0] == c.Name
+
+		buffer.WriteString("    ")
+
+		if writeColumnDefinition(buffer, d, c, isPrimaryKey) {
+			primaryKeyConsumed = true
+		}
+	}
+
+	if len(t.PrimaryKey) > 0 && !primaryKeyConsumed {
+		buffer.WriteString(",\n    PRIMARY KEY (")
+		buffer.WriteString(quoteNameList(d, t.PrimaryKey))
+		buffer.WriteString(")")
+	}
+
+	for _, index := range t.Indexes {
+		if !isTableIndex(d, t, index) {
+			continue
+		}
+
+		buffer.WriteString(",\n    ")
+
+		if index.Unique {
+			buffer.WriteString("UNIQUE ")
+		}
+
+		buffer.WriteString("KEY ")
+		buffer.WriteString(d.quoteIdentifier(index.Name))
+		buffer.WriteString(" (")
+		buffer.WriteString(quoteNameList(d, index.Columns))
+		buffer.WriteString(")")
+	}
+
+	buffer.WriteString("\n);\n")
+}
+
+// writeCreateIndexes 输出没有在CREATE TABLE中声明的索引
+func writeCreateIndexes(buffer *bytes.Buffer, d ddlDialect, t *TableSchema) {
+	for _, index := range t.Indexes {
+		if !isTableIndex(d, t, index) {
+			writeCreateIndex(buffer, d, t.Name, index)
+		}
+	}
+}
+
+// isTableIndex 判断索引是否在CREATE TABLE中声明：MySQL中以自增列开头的索引必须在建表时存在，否则报错1075
+func isTableIndex(d ddlDialect, t *TableSchema, index *IndexSchema) bool {
+	if !d.identityNeedsKey() || len(index.Columns) == 0 {
+		return false
+	}
+
+	c, ok := t.GetColumn(index.Columns[0])
+
+	return ok && c.Identity
+}
+
+func writeColumnDefinition(buffer *bytes.Buffer, d ddlDialect, c *ColumnSchema, isPrimaryKey bool) bool {
+	var consumed bool
+
+	buffer.WriteString(quoteName(d, c.Name))
+	buffer.WriteString(" ")
+	buffer.WriteString(c.Type)
+
+	if c.Nullable {
+		buffer.WriteString(" NULL")
+	} else {
+		buffer.WriteString(" NOT NULL")
+	}
+
+	if c.Identity {
+		var clause string
+
+		clause, consumed = d.identityClause(isPrimaryKey)
+		buffer.WriteString(clause)
+	} else if c.Default != "" {
+		buffer.WriteString(" DEFAULT ")
+		buffer.WriteString(c.Default)
+	}
+
+	if c.Unique {
+		buffer.WriteString(" UNIQUE")
+	}
+
+	return consumed
+}
+
+func writeCreateIndex(buffer *bytes.Buffer, d ddlDialect, tableName string, index *IndexSchema) {
+	if index.Unique {
+		buffer.WriteString("CREATE UNIQUE INDEX ")
+	} else {
+		buffer.WriteString("CREATE INDEX ")
+	}
+
+	buffer.WriteString(d.quoteIdentifier(index.Name))
+	buffer.WriteString(" ON ")
+	buffer.WriteString(quoteName(d, tableName))
+	buffer.WriteString(" (")
+	buffer.WriteString(quoteNameList(d, index.Columns))
+	buffer.WriteString(");\n")
+}
+
+func quoteName(d sqlDialect, name string) string {
+	parts := strings.Split(name, ".")
+
+	for i, part := range parts {
+		parts[i] = d.quoteIdentifier(part)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func quoteNameList(d sqlDialect, names []string) string {
+	list := make([]string, len(names))
+
+	for i, name := range names {
+		list[i] = d.quoteIdentifier(name)
+	}
+
+	return strings.Join(list, ", ")
+}
+
+func sizedType(name string, size int, defaultSize string) string {
+	if size > 0 {
+		return name + "(" + strconv.Itoa(size) + ")"
+	}
+
+	if defaultSize == "" {
+		return name
+	}
+
+	return name + "(" + defaultSize + ")"
+}
+
+func (defaultDialect) columnType(baseType string, size int) string {
+	switch baseType {
+	case "string":
+		return sizedType("VARCHAR", size, "255")
+	case "bool":
+		return "BOOLEAN"
+	case "int8", "int16", "byte":
+		return "SMALLINT"
+	case "int32", "uint16":
+		return "INTEGER"
+	case "int", "int64", "uint", "uint32", "uint64":
+		return "BIGINT"
+	case "float32":
+		return "REAL"
+	case "float64":
+		return "DOUBLE PRECISION"
+	case "time.Time":
+		return "TIMESTAMP"
+	case "[]byte":
+		return "BLOB"
+	}
+
+	return ""
+}
+
+func (defaultDialect) identityClause(isPrimaryKey bool) (string, bool) {
+	return " GENERATED BY DEFAULT AS IDENTITY", false
+}
+
+func (defaultDialect) identityNeedsKey() bool {
+	return false
+}
+
+func (mysqlDialect) columnType(baseType string, size int) string {
+	switch baseType {
+	case "string":
+		return sizedType("VARCHAR", size, "255")
+	case "bool":
+		return "TINYINT(1)"
+	case "int8":
+		return "TINYINT"
+	case "byte":
+		return "TINYINT UNSIGNED"
+	case "int16":
+		return "SMALLINT"
+	case "uint16":
+		return "SMALLINT UNSIGNED"
+	case "int32":
+		return "INT"
+	case "uint32":
+		return "INT UNSIGNED"
+	case "int", "int64":
+		return "BIGINT"
+	case "uint", "uint64":
+		return "BIGINT UNSIGNED"
+	case "float32":
+		return "FLOAT"
+	case "float64":
+		return "DOUBLE"
+	case "time.Time":
+		return "DATETIME"
+	case "[]byte":
+		if size > 0 {
+			return sizedType("VARBINARY", size, "")
+		}
+
+		return "BLOB"
+	}
+
+	return ""
+}
+
+func (mysqlDialect) identityClause(isPrimaryKey bool) (string, bool) {
+	return " AUTO_INCREMENT", false
+}
+
+func (mysqlDialect) identityNeedsKey() bool {
+	return true
+}
+
+func (postgresDialect) columnType(baseType string, size int) string {
+	switch baseType {
+	case "string":
+		if size > 0 {
+			return sizedType("VARCHAR", size, "")
+		}
+
+		return "TEXT"
+	case "bool":
+		return "BOOLEAN"
+	case "int8", "int16", "byte":
+		return "SMALLINT"
+	case "int32", "uint16":
+		return "INTEGER"
+	case "int", "int64", "uint", "uint32", "uint64":
+		return "BIGINT"
+	case "float32":
+		return "REAL"
+	case "float64":
+		return "DOUBLE PRECISION"
+	case "time.Time":
+		return "TIMESTAMP"
+	case "[]byte":
+		return "BYTEA"
+	}
+
+	return ""
+}
+
+func (postgresDialect) identityClause(isPrimaryKey bool) (string, bool) {
+	return " GENERATED BY DEFAULT AS IDENTITY", false
+}
+
+func (postgresDialect) identityNeedsKey() bool {
+	return false
+}
+
+func (sqliteDialect) columnType(baseType string, size int) string {
+	switch baseType {
+	case "string":
+		return "TEXT"
+	case "bool", "int8", "int16", "byte", "int32", "uint16",
+		"int", "int64", "uint", "uint32", "uint64":
+		return "INTEGER"
+	case "float32", "float64":
+		return "REAL"
+	case "time.Time":
+		return "DATETIME"
+	case "[]byte":
+		return "BLOB"
+	}
+
+	return ""
+}
+
+func (sqliteDialect) identityClause(isPrimaryKey bool) (string, bool) {
+	if isPrimaryKey {
+		return " PRIMARY KEY AUTOINCREMENT", true
+	}
+
+	return "", false
+}
+
+func (sqliteDialect) identityNeedsKey() bool {
+	return false
+}
+
+func (sqlServerDialect) columnType(baseType string, size int) string {
+	switch baseType {
+	case "string":
+		return sizedType("NVARCHAR", size, "MAX")
+	case "bool":
+		return "BIT"
+	case "byte":
+		return "TINYINT"
+	case "int8", "int16":
+		return "SMALLINT"
+	case "int32", "uint16":
+		return "INT"
+	case "int", "int64", "uint", "uint32", "uint64":
+		return "BIGINT"
+	case "float32":
+		return "REAL"
+	case "float64":
+		return "FLOAT"
+	case "time.Time":
+		return "DATETIME2"
+	case "[]byte":
+		return sizedType("VARBINARY", size, "MAX")
+	}
+
+	return ""
+}
+
+func (sqlServerDialect) identityClause(isPrimaryKey bool) (string, bool) {
+	return " IDENTITY(1,1)", false
+}
+
+func (sqlServerDialect) identityNeedsKey() bool {
+	return false
+}
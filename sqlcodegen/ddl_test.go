@@ -0,0 +1,87 @@
+package sqlcodegen
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// TestWriteDDL 比较testdata/ddl中的模型在各方言下生成的DDL，go test -update 更新期望的结果
+func TestWriteDDL(t *testing.T) {
+	for _, dialect := range []Dialect{DialectDefault, DialectMySQL, DialectPostgres, DialectSQLite, DialectSQLServer} {
+		name := string(dialect)
+
+		if name == "" {
+			name = "default"
+		}
+
+		schema, err := LoadSchema(dialect, filepath.Join("testdata", "ddl", "ddl.go"))
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		var buffer bytes.Buffer
+
+		if err := WriteDDL(&buffer, schema, dialect); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		goldenFile := filepath.Join("testdata", "ddl", "ddl."+name+".sql")
+
+		if *update {
+			if err := ioutil.WriteFile(goldenFile, buffer.Bytes(), 0666); err != nil {
+				t.Fatal(err)
+			}
+
+			continue
+		}
+
+		want, err := ioutil.ReadFile(goldenFile)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(buffer.Bytes(), want) {
+			t.Errorf("%s: DDL differs from %s\n%s", name, goldenFile, buffer.Bytes())
+		}
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	schema, err := LoadSchema(DialectPostgres, filepath.Join("testdata", "ddl", "ddl.go"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users, ok := schema.GetTable("users")
+
+	if !ok {
+		t.Fatalf("no table users in %+v", schema.Tables)
+	}
+
+	// 没有pk标签时自增列为主键
+	if len(users.PrimaryKey) != 1 || users.PrimaryKey[0] != "UserID" {
+		t.Errorf("primary key of users %v, want [UserID]", users.PrimaryKey)
+	}
+
+	if email, _ := users.GetColumn("Email"); email == nil || !email.Nullable {
+		t.Errorf("Email %+v, want a nullable column", email)
+	}
+
+	items, _ := schema.GetTable("OrderItem")
+
+	if items == nil || len(items.PrimaryKey) != 2 || len(items.Indexes) != 1 || items.Indexes[0].Name != "ix_product" {
+		t.Errorf("OrderItem %+v, want a composite primary key and index ix_product", items)
+	}
+
+	if _, err := LoadSchema(Dialect("oracle"), filepath.Join("testdata", "ddl", "ddl.go")); err == nil {
+		t.Error("LoadSchema with an unknown dialect succeeded")
+	}
+}
@@ -12,6 +12,7 @@ const (
 	ReturnRecord
 	ReturnRecordSet
 	ReturnRecordChannel
+	ReturnRecordIter
 )
 
 func From(table interface{}) {}
@@ -22,20 +23,72 @@ func SelectAll(table interface{}) {}
 
 func Where(condition bool) {}
 
+func Optional(condition bool) bool { return condition }
+
+func In(column interface{}, values interface{}) bool { return false }
+
+func NotIn(column interface{}, values interface{}) bool { return false }
+
+func Like(column interface{}, pattern interface{}) bool { return false }
+
+func NotLike(column interface{}, pattern interface{}) bool { return false }
+
+func Between(column interface{}, from interface{}, to interface{}) bool { return false }
+
+func IsNull(column interface{}) bool { return false }
+
+func NotNull(column interface{}) bool { return false }
+
+func InnerJoin(table interface{}, condition bool) {}
+
+func LeftJoin(table interface{}, condition bool) {}
+
+func RightJoin(table interface{}, condition bool) {}
+
+func FullJoin(table interface{}, condition bool) {}
+
 func InsertAll(table interface{}) {}
 
+func Insert(columns ...interface{}) {}
+
 func Update(column interface{}, value interface{}) {}
 
 func Delete(table interface{}) {}
 
+func GroupBy(columns ...interface{}) {}
+
+func Having(condition bool) {}
+
+func Count(column ...interface{}) int64 { return 0 }
+
+func Sum(column interface{}) float64 { return 0 }
+
+func Avg(column interface{}) float64 { return 0 }
+
+func Min(column interface{}) float64 { return 0 }
+
+func Max(column interface{}) float64 { return 0 }
+
 func OrderBy(column interface{}) {}
 
 func OrderByDescending(column interface{}) {}
 
+func Limit(rows interface{}) {}
+
+func Offset(rows interface{}) {}
+
+func Paginate(page interface{}, size interface{}) {}
+
 func SetReturnType(t ReturnType) {}
 
 func ExecProcedure(procName string, args ...interface{}) {}
 
+func Transactional() {}
+
+func Out(dest interface{}) interface{} { return dest }
+
 func SetPackageName(packageName string) {}
 
 func SetChannelBufferSize(size int) {}
+
+func SetResultTypeName(typeName string) {}
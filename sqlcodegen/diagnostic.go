@@ -0,0 +1,194 @@
+package sqlcodegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+
+	return "error"
+}
+
+// Diagnostic 编译描述文件时产生的错误或警告，Snippet为出错位置所在的源代码行
+type Diagnostic struct {
+	Pos      token.Position
+	Severity Severity
+	Message  string
+	Snippet  string
+}
+
+func (d *Diagnostic) Error() string {
+	var text string
+
+	if d.Pos.IsValid() {
+		text = d.Pos.String() + ": "
+	}
+
+	text += d.Severity.String() + ": " + d.Message
+
+	if d.Snippet != "" {
+		text += "\n\t" + d.Snippet
+	}
+
+	return text
+}
+
+// Diagnostics 一次编译产生的所有诊断信息，包含错误时作为Compile的error返回
+type Diagnostics []*Diagnostic
+
+func (list Diagnostics) Error() string {
+	lines := make([]string, len(list))
+
+	for i, d := range list {
+		lines[i] = d.Error()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (list Diagnostics) HasErrors() bool {
+	for _, d := range list {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (list Diagnostics) sort() {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i].Pos, list[j].Pos
+
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+
+		return a.Column < b.Column
+	})
+}
+
+func (context *parseContext) newDiagnostic(severity Severity, pos token.Pos, format string, args ...interface{}) *Diagnostic {
+	d := &Diagnostic{Severity: severity, Message: fmt.Sprintf(format, args...)}
+
+	if pos.IsValid() {
+		d.Pos = context.fset.Position(pos)
+		d.Snippet = context.getSourceLine(pos)
+	}
+
+	return d
+}
+
+func (context *parseContext) newError(node ast.Node, format string, args ...interface{}) error {
+	return context.newDiagnostic(SeverityError, node.Pos(), format, args...)
+}
+
+func (context *parseContext) warn(node ast.Node, format string, args ...interface{}) {
+	context.diagnostics = append(context.diagnostics, context.newDiagnostic(SeverityWarning, node.Pos(), format, args...))
+}
+
+// addError 记录错误并继续编译，非Diagnostic的错误不包含位置
+func (context *parseContext) addError(err error) {
+	switch inst := err.(type) {
+	case *Diagnostic:
+		context.diagnostics = append(context.diagnostics, inst)
+	case Diagnostics:
+		context.diagnostics = append(context.diagnostics, inst...)
+	default:
+		context.diagnostics = append(context.diagnostics, &Diagnostic{Severity: SeverityError, Message: err.Error()})
+	}
+}
+
+// finish 返回包含错误的诊断信息，只有警告时交给warn处理，warn为nil时忽略警告
+func (context *parseContext) finish(warn func(*Diagnostic)) error {
+	context.diagnostics.sort()
+
+	if context.diagnostics.HasErrors() {
+		return context.diagnostics
+	}
+
+	if warn != nil {
+		for _, d := range context.diagnostics {
+			warn(d)
+		}
+	}
+
+	return nil
+}
+
+// parseFile 解析源文件并保存源代码，解析错误转换为Diagnostics
+func (context *parseContext) parseFile(fileName string, mode parser.Mode) (*ast.File, error) {
+	src, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if context.sources == nil {
+		context.sources = make(map[string][]byte)
+	}
+
+	file, err := parser.ParseFile(context.fset, fileName, src, mode)
+	context.sources[fileName] = src
+
+	if list, ok := err.(scanner.ErrorList); ok {
+		var diagnostics Diagnostics
+
+		for _, e := range list {
+			d := &Diagnostic{Pos: e.Pos, Severity: SeverityError, Message: e.Msg, Snippet: getLine(src, e.Pos.Line)}
+
+			diagnostics = append(diagnostics, d)
+		}
+
+		return nil, diagnostics
+	}
+
+	return file, err
+}
+
+func (context *parseContext) getSourceLine(pos token.Pos) string {
+	file := context.fset.File(pos)
+
+	if file == nil {
+		return ""
+	}
+
+	src, ok := context.sources[file.Name()]
+
+	if !ok {
+		return ""
+	}
+
+	return getLine(src, file.Line(pos))
+}
+
+func getLine(src []byte, line int) string {
+	lines := strings.SplitN(string(src), "\n", line+1)
+
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	return strings.TrimSpace(lines[line-1])
+}
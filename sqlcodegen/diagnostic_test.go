@@ -0,0 +1,52 @@
+package sqlcodegen
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWarnings(t *testing.T) {
+	var warnings []*Diagnostic
+
+	opts := Options{Warn: func(d *Diagnostic) { warnings = append(warnings, d) }}
+
+	if err := Compile(filepath.Join("testdata", "warn", "warn.go"), filepath.Join(t.TempDir(), "warn.go"), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(warnings) != 1 || warnings[0].Severity != SeverityWarning ||
+		!strings.Contains(warnings[0].Message, "unsupported sqlcodegen.From in init") {
+		t.Fatalf("warnings = %v, want the unsupported call in init", warnings)
+	}
+
+	if warnings[0].Pos.Line != 12 {
+		t.Errorf("warning at line %d, want 12", warnings[0].Pos.Line)
+	}
+}
+
+func TestWarningsIgnoredWithoutWarn(t *testing.T) {
+	r, w, err := os.Pipe()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+
+	err = Compile(filepath.Join("testdata", "warn", "warn.go"), filepath.Join(t.TempDir(), "warn.go"), Options{})
+
+	os.Stdout = stdout
+	w.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output, _ := io.ReadAll(r); len(output) != 0 {
+		t.Errorf("Compile wrote to stdout: %q", output)
+	}
+}
@@ -0,0 +1,285 @@
+package sqlcodegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect 数据库方言，决定生成SQL语句的参数占位符、标识符引用方式等
+type Dialect string
+
+const (
+	DialectDefault   Dialect = ""
+	DialectMySQL     Dialect = "mysql"
+	DialectPostgres  Dialect = "postgres"
+	DialectSQLite    Dialect = "sqlite"
+	DialectSQLServer Dialect = "sqlserver"
+)
+
+var dialectAliases = map[string]Dialect{
+	"":           DialectDefault,
+	"default":    DialectDefault,
+	"mysql":      DialectMySQL,
+	"postgres":   DialectPostgres,
+	"postgresql": DialectPostgres,
+	"pg":         DialectPostgres,
+	"sqlite":     DialectSQLite,
+	"sqlite3":    DialectSQLite,
+	"sqlserver":  DialectSQLServer,
+	"mssql":      DialectSQLServer,
+}
+
+func ParseDialect(name string) (Dialect, error) {
+	dialect, ok := dialectAliases[strings.ToLower(name)]
+
+	if !ok {
+		return DialectDefault, fmt.Errorf("error: unknown dialect %q", name)
+	}
+
+	return dialect, nil
+}
+
+func NewSQLBuilder(dialect Dialect) (SQLBuilder, error) {
+	d, err := getSQLDialect(dialect)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newSQLBuilder(d), nil
+}
+
+func getSQLDialect(dialect Dialect) (sqlDialect, error) {
+	switch dialect {
+	case DialectDefault:
+		return defaultDialect{}, nil
+	case DialectMySQL:
+		return mysqlDialect{}, nil
+	case DialectPostgres:
+		return postgresDialect{}, nil
+	case DialectSQLite:
+		return sqliteDialect{}, nil
+	case DialectSQLServer:
+		return sqlServerDialect{}, nil
+	}
+
+	return nil, fmt.Errorf("error: unknown dialect %q", string(dialect))
+}
+
+type sqlDialect interface {
+	quoteIdentifier(name string) string
+	placeholder(index int) string
+	deleteKeyword() string
+	useTop() bool
+	maxLimit() string
+	bindType() string
+	procedureKeyword() (keyword string, withParen bool)
+	// outParameter 返回输出参数之后的关键字，ok为false时驱动不支持通过sql.Out读取输出参数
+	outParameter() (keyword string, ok bool)
+	identityMode() identityMode
+}
+
+// identityMode 插入记录后读取自增列的方式
+type identityMode int
+
+const (
+	identityNone identityMode = iota
+	identityLastInsertID
+	identityReturning
+	identityOutput
+)
+
+type defaultDialect struct{}
+
+func (defaultDialect) quoteIdentifier(name string) string {
+	return name
+}
+
+func (defaultDialect) placeholder(index int) string {
+	return "?"
+}
+
+func (defaultDialect) deleteKeyword() string {
+	return "DELETE "
+}
+
+func (defaultDialect) useTop() bool {
+	return false
+}
+
+func (defaultDialect) maxLimit() string {
+	return ""
+}
+
+func (defaultDialect) bindType() string {
+	return "sqlutil.BindQuestion"
+}
+
+func (defaultDialect) procedureKeyword() (string, bool) {
+	return "CALL ", true
+}
+
+func (defaultDialect) outParameter() (string, bool) {
+	return "", true
+}
+
+func (defaultDialect) identityMode() identityMode {
+	return identityNone
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) quoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+func (mysqlDialect) placeholder(index int) string {
+	return "?"
+}
+
+func (mysqlDialect) deleteKeyword() string {
+	return "DELETE FROM "
+}
+
+func (mysqlDialect) useTop() bool {
+	return false
+}
+
+func (mysqlDialect) maxLimit() string {
+	return "18446744073709551615"
+}
+
+func (mysqlDialect) bindType() string {
+	return "sqlutil.BindQuestion"
+}
+
+func (mysqlDialect) procedureKeyword() (string, bool) {
+	return "CALL ", true
+}
+
+func (mysqlDialect) outParameter() (string, bool) {
+	return "", false
+}
+
+func (mysqlDialect) identityMode() identityMode {
+	return identityLastInsertID
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) quoteIdentifier(name string) string {
+	return quoteWithDoubleQuote(name)
+}
+
+func (postgresDialect) placeholder(index int) string {
+	return "$" + strconv.Itoa(index)
+}
+
+func (postgresDialect) deleteKeyword() string {
+	return "DELETE FROM "
+}
+
+func (postgresDialect) useTop() bool {
+	return false
+}
+
+func (postgresDialect) maxLimit() string {
+	return ""
+}
+
+func (postgresDialect) bindType() string {
+	return "sqlutil.BindDollar"
+}
+
+func (postgresDialect) procedureKeyword() (string, bool) {
+	return "CALL ", true
+}
+
+func (postgresDialect) outParameter() (string, bool) {
+	return "", false
+}
+
+func (postgresDialect) identityMode() identityMode {
+	return identityReturning
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) quoteIdentifier(name string) string {
+	return quoteWithDoubleQuote(name)
+}
+
+func (sqliteDialect) placeholder(index int) string {
+	return "?"
+}
+
+func (sqliteDialect) deleteKeyword() string {
+	return "DELETE FROM "
+}
+
+func (sqliteDialect) useTop() bool {
+	return false
+}
+
+func (sqliteDialect) maxLimit() string {
+	return "-1"
+}
+
+func (sqliteDialect) bindType() string {
+	return "sqlutil.BindQuestion"
+}
+
+func (sqliteDialect) procedureKeyword() (string, bool) {
+	return "", false
+}
+
+func (sqliteDialect) outParameter() (string, bool) {
+	return "", false
+}
+
+func (sqliteDialect) identityMode() identityMode {
+	return identityReturning
+}
+
+type sqlServerDialect struct{}
+
+func (sqlServerDialect) quoteIdentifier(name string) string {
+	return "[" + strings.Replace(name, "]", "]]", -1) + "]"
+}
+
+func (sqlServerDialect) placeholder(index int) string {
+	return "@p" + strconv.Itoa(index)
+}
+
+func (sqlServerDialect) deleteKeyword() string {
+	return "DELETE FROM "
+}
+
+func (sqlServerDialect) useTop() bool {
+	return true
+}
+
+func (sqlServerDialect) maxLimit() string {
+	return ""
+}
+
+func (sqlServerDialect) bindType() string {
+	return "sqlutil.BindAt"
+}
+
+func (sqlServerDialect) procedureKeyword() (string, bool) {
+	return "EXEC ", false
+}
+
+func (sqlServerDialect) outParameter() (string, bool) {
+	return " OUTPUT", true
+}
+
+func (sqlServerDialect) identityMode() identityMode {
+	return identityOutput
+}
+
+func quoteWithDoubleQuote(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
@@ -0,0 +1,141 @@
+package sqlcodegen
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDialectSQL(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		queries []string
+	}{
+		{DialectDefault, []string{
+			"SELECT UserID, UserName\nFROM users\nWHERE UserName = ?\nORDER BY UserID\nLIMIT ?\nOFFSET ?\n",
+			"SELECT UserName\nFROM users\nORDER BY UserID\nLIMIT ?\n",
+			"INSERT INTO users(UserName)\nVALUES(?)",
+			"UPDATE users\nSET UserName = ?\nWHERE UserID = ?\n",
+		}},
+		{DialectMySQL, []string{
+			"SELECT `UserID`, `UserName`\nFROM `users`\nWHERE `UserName` = ?\nORDER BY `UserID`\nLIMIT ?\nOFFSET ?\n",
+			"SELECT `UserName`\nFROM `users`\nORDER BY `UserID`\nLIMIT ?\n",
+			"INSERT INTO `users`(`UserName`)\nVALUES(?)",
+			"UPDATE `users`\nSET `UserName` = ?\nWHERE `UserID` = ?\n",
+		}},
+		{DialectPostgres, []string{
+			"SELECT \"UserID\", \"UserName\"\nFROM \"users\"\nWHERE \"UserName\" = $1\nORDER BY \"UserID\"\nLIMIT $2\nOFFSET $3\n",
+			"SELECT \"UserName\"\nFROM \"users\"\nORDER BY \"UserID\"\nLIMIT $1\n",
+			"INSERT INTO \"users\"(\"UserName\")\nVALUES($1)\nRETURNING \"UserID\"",
+			"UPDATE \"users\"\nSET \"UserName\" = $1\nWHERE \"UserID\" = $2\n",
+		}},
+		{DialectSQLite, []string{
+			"SELECT \"UserID\", \"UserName\"\nFROM \"users\"\nWHERE \"UserName\" = ?\nORDER BY \"UserID\"\nLIMIT ?\nOFFSET ?\n",
+			"SELECT \"UserName\"\nFROM \"users\"\nORDER BY \"UserID\"\nLIMIT ?\n",
+			"INSERT INTO \"users\"(\"UserName\")\nVALUES(?)\nRETURNING \"UserID\"",
+			"UPDATE \"users\"\nSET \"UserName\" = ?\nWHERE \"UserID\" = ?\n",
+		}},
+		{DialectSQLServer, []string{
+			"SELECT [UserID], [UserName]\nFROM [users]\nWHERE [UserName] = @p1\nORDER BY [UserID]\nOFFSET @p2 ROWS\nFETCH NEXT @p3 ROWS ONLY\n",
+			"SELECT TOP (@p1) [UserName]\nFROM [users]\nORDER BY [UserID]\n",
+			"INSERT INTO [users]([UserName])\nOUTPUT INSERTED.[UserID]\nVALUES(@p1)",
+			"UPDATE [users]\nSET [UserName] = @p1\nWHERE [UserID] = @p2\n",
+		}},
+	}
+
+	for _, test := range tests {
+		code := generateTestPackage(t, "dialect", Options{Dialect: test.dialect})
+
+		for _, query := range test.queries {
+			if want := "const query = " + strconv.Quote(query); !strings.Contains(code, want) {
+				t.Errorf("%s: generated code does not contain %s\n%s", test.dialect, want, code)
+			}
+		}
+	}
+
+	// SQL Server的OFFSET在FETCH之前，参数的顺序与其他方言不同
+	if code := generateTestPackage(t, "dialect", Options{Dialect: DialectSQLServer}); !strings.Contains(code, "}, userName, skip, take)") {
+		t.Errorf("sqlserver: arguments are not in OFFSET, FETCH order\n%s", code)
+	}
+}
+
+func TestProcedureSQL(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		queries []string
+	}{
+		{DialectDefault, []string{"CALL sp_delete_user(?, 1)", "CALL sp_list_users(?)"}},
+		{DialectMySQL, []string{"CALL `sp_delete_user`(?, 1)", "CALL `sp_list_users`(?)"}},
+		{DialectPostgres, []string{"CALL \"sp_delete_user\"($1, 1)", "CALL \"sp_list_users\"($1)"}},
+		{DialectSQLServer, []string{"EXEC [sp_delete_user] @p1, 1", "EXEC [sp_list_users] @p1"}},
+	}
+
+	for _, test := range tests {
+		code := generateTestPackage(t, "procedure", Options{Dialect: test.dialect})
+
+		for _, want := range []string{
+			"const query = " + strconv.Quote(test.queries[0]),
+			"const query = " + strconv.Quote(test.queries[1]),
+			"func DeleteUserProc(ctx context.Context, db sqlutil.DbObject, userID int64) (sql.Result, error)",
+			"func ListUsersProc(ctx context.Context, db sqlutil.DbObject, userName string) ([]*User, error)",
+		} {
+			if !strings.Contains(code, want) {
+				t.Errorf("%s: generated code does not contain %s\n%s", test.dialect, want, code)
+			}
+		}
+	}
+
+	err := Compile(filepath.Join("testdata", "procedure", "procedure.go"), filepath.Join(t.TempDir(), "procedure.go"), Options{Dialect: DialectSQLite})
+
+	if err == nil || !strings.Contains(err.Error(), "error: unsupported sqlcodegen.ExecProcedure") {
+		t.Errorf("sqlite: Compile = %v, want an unsupported ExecProcedure error", err)
+	}
+}
+
+func TestProcedureOutParameter(t *testing.T) {
+	queries := map[Dialect]string{
+		DialectDefault:   "CALL sp_add_user(?, ?)",
+		DialectSQLServer: "EXEC [sp_add_user] @p1, @p2 OUTPUT",
+	}
+
+	for dialect, query := range queries {
+		code := generateTestPackage(t, "procout", Options{Dialect: dialect})
+
+		for _, want := range []string{"const query = " + strconv.Quote(query), "sqlutil.Exec(ctx, db, query, userName, sql.Out{Dest: userID})"} {
+			if !strings.Contains(code, want) {
+				t.Errorf("%s: generated code does not contain %s\n%s", dialect, want, code)
+			}
+		}
+	}
+
+	// 驱动不支持sql.Out时在生成代码时报错，而不是生成运行时总是失败的代码
+	for _, dialect := range []Dialect{DialectMySQL, DialectPostgres} {
+		err := Compile(filepath.Join("testdata", "procout", "procout.go"), filepath.Join(t.TempDir(), "procout.go"), Options{Dialect: dialect})
+
+		if err == nil || !strings.Contains(err.Error(), "error: sqlcodegen.Out is not supported by the dialect") {
+			t.Errorf("%s: Compile = %v, want an error about sqlcodegen.Out", dialect, err)
+		}
+	}
+}
+
+func TestParseDialect(t *testing.T) {
+	tests := map[string]Dialect{
+		"":           DialectDefault,
+		"MySQL":      DialectMySQL,
+		"postgresql": DialectPostgres,
+		"pg":         DialectPostgres,
+		"sqlite3":    DialectSQLite,
+		"mssql":      DialectSQLServer,
+	}
+
+	for name, want := range tests {
+		if d, err := ParseDialect(name); err != nil || d != want {
+			t.Errorf("ParseDialect(%q) = %q, %v, want %q", name, d, err, want)
+		}
+	}
+
+	if _, err := ParseDialect("oracle"); err == nil {
+		t.Error("ParseDialect(\"oracle\") succeeded")
+	}
+}
@@ -0,0 +1,53 @@
+package sqlcodegen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestExampleAccount 用example/account检查各方言的生成结果，默认方言的结果即example/account/gen/account.go
+func TestExampleAccount(t *testing.T) {
+	for _, dialect := range []Dialect{DialectDefault, DialectMySQL, DialectPostgres, DialectSQLite, DialectSQLServer} {
+		goldenFile := filepath.Join("..", "example", "account", "gen", "account.go")
+
+		if dialect != DialectDefault {
+			goldenFile = filepath.Join("testdata", "golden", "account."+string(dialect)+".go.golden")
+		}
+
+		opts := Options{
+			Dialect: dialect,
+			// 生成代码无法通过类型检查，或者跳过了类型检查时都会产生告警
+			Warn: func(d *Diagnostic) { t.Errorf("dialect %q: %v", dialect, d) },
+		}
+
+		files, err := GeneratePackage(filepath.Join("..", "example", "account"), opts)
+
+		if err != nil {
+			t.Fatalf("dialect %q: %v", dialect, err)
+		}
+
+		if len(files) != 1 {
+			t.Fatalf("dialect %q: %d generated files, want 1", dialect, len(files))
+		}
+
+		if *update {
+			if err := ioutil.WriteFile(goldenFile, files[0].Content, 0666); err != nil {
+				t.Fatal(err)
+			}
+
+			continue
+		}
+
+		want, err := ioutil.ReadFile(goldenFile)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(files[0].Content, want) {
+			t.Errorf("dialect %q: generated code differs from %s, run go test -update after checking the change\n%s", dialect, goldenFile, files[0].Content)
+		}
+	}
+}
@@ -1,33 +1,39 @@
 package sqlcodegen
 
 import (
-	"errors"
+	"bytes"
 	"fmt"
 	"go/ast"
-	"go/parser"
+	"go/format"
 	"go/token"
-	"os"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"iter"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 )
 
 func newArgError(context *parseContext, node ast.Node) error {
-	return fmt.Errorf(
-		"error: function argument error(%v)",
-		context.fset.Position(node.Pos()))
+	if callExpr, ok := node.(*ast.CallExpr); ok {
+		return context.newError(node, "invalid arguments of %s", types.ExprString(callExpr.Fun))
+	}
+
+	return context.newError(node, "function argument error")
 }
 
 func newTypeDefError(context *parseContext, typeName string, node ast.Node) error {
-	return fmt.Errorf(
-		"error: type %s definition error(%v)",
-		typeName, context.fset.Position(node.Pos()))
+	return context.newError(node, "type %s definition error", typeName)
 }
 
 func newUnsupportedError(context *parseContext, node ast.Node) error {
-	return fmt.Errorf(
-		"warn: unsupported(%v)",
-		context.fset.Position(node.Pos()))
+	if callExpr, ok := node.(*ast.CallExpr); ok {
+		return context.newError(node, "unsupported %s", types.ExprString(callExpr.Fun))
+	}
+
+	return context.newError(node, "unsupported expression %s", types.ExprString(node.(ast.Expr)))
 }
 
 type table struct {
@@ -38,6 +44,11 @@ type table struct {
 	columns []*column
 }
 
+// shortName 返回不含包名的模型名称，其他包中的模型名称为 models.User
+func (t *table) shortName() string {
+	return t.name[strings.LastIndex(t.name, ".")+1:]
+}
+
 func (t *table) getColumn(name string) (*column, bool) {
 	for _, col := range t.columns {
 		if col.name == name {
@@ -49,20 +60,65 @@ func (t *table) getColumn(name string) (*column, bool) {
 }
 
 type column struct {
+	table      *table
 	name       string
 	columnName string
 	tag        string
 	sysType    string
 	isNull     bool
 	isIdentity bool
+
+	isPrimaryKey bool
+	isUnique     bool
+	uniqueName   string
+	indexName    string
+	size         int
+	defaultValue string
+	columnType   string
 }
 
 type parseContext struct {
-	fset       *token.FileSet
-	entity     map[string]*table
-	tables     []*table
-	generator  *codeGenerator
-	sqlBuilder SQLBuilder
+	fset        *token.FileSet
+	entity      map[string]*table
+	tables      []*table
+	resultTypes map[string]*resultType
+	generator   *codeGenerator
+	sqlBuilder  SQLBuilder
+	useContext  bool
+	typeInfo    *types.Info
+	pkg         *types.Package
+	importer    *modelImporter
+	sources     map[string][]byte
+	diagnostics Diagnostics
+	files       []*ast.File
+	packageName string
+}
+
+type resultType struct {
+	name      string
+	fields    []*resultField
+	isGenType bool
+}
+
+type resultField struct {
+	name    string
+	sysType string
+	// scanNull 字段类型不能表示NULL但列可以为NULL，通过sqlutil.ScanNullable扫描
+	scanNull bool
+}
+
+func (r *resultType) equals(other *resultType) bool {
+	if len(r.fields) != len(other.fields) {
+		return false
+	}
+
+	for i, f := range r.fields {
+		if f.name != other.fields[i].name || f.sysType != other.fields[i].sysType {
+			return false
+		}
+	}
+
+	return true
 }
 
 func (context *parseContext) getEntityWithExpr(expr ast.Expr) (*table, bool) {
@@ -84,88 +140,149 @@ func (context *parseContext) getEntityWithExpr(expr ast.Expr) (*table, bool) {
 	return table, ok
 }
 
+func (context *parseContext) getColumnWithExpr(expr ast.Expr) (*SQLColumnExpression, bool) {
+	entity, ok := context.getEntityWithExpr(expr)
+
+	if !ok {
+		return nil, false
+	}
+
+	selector, ok := expr.(*ast.SelectorExpr)
+
+	if !ok {
+		return nil, false
+	}
+
+	col, ok := entity.getColumn(selector.Sel.Name)
+
+	if !ok {
+		return nil, false
+	}
+
+	sqlColExpr := &SQLColumnExpression{}
+	sqlColExpr.source = col
+	sqlColExpr.columnName = col.columnName
+	sqlColExpr.tableName = entity.tableName
+	sqlColExpr.entityName = selector.X.(*ast.Ident).Name
+
+	return sqlColExpr, true
+}
+
 type Options struct {
 	SQLBuilder SQLBuilder
+	Dialect    Dialect
+	// NoContext 为true时生成的函数不接收context.Context参数，使用context.Background()
+	NoContext bool
+	// Warn 编译成功时接收警告，为nil时忽略警告；编译失败时警告与错误一起作为Diagnostics返回
+	Warn func(*Diagnostic)
+	// SingleFile 不为空时CompilePackage将所有描述文件生成到这一个文件中
+	SingleFile string
 }
 
+// Compile 编译srcFileName所在包的所有描述文件，只生成srcFileName中定义的模型与函数
 func Compile(srcFileName string, outFileName string, opts Options) error {
-	context := parseContext{}
-	context.entity = make(map[string]*table)
-	context.fset = token.NewFileSet()
-	context.generator = newGenerator()
+	var buffer bytes.Buffer
 
-	if opts.SQLBuilder == nil {
-		context.sqlBuilder = newDefaultSQLBuilder()
-	} else {
-		context.sqlBuilder = opts.SQLBuilder
+	if err := CompileTo(srcFileName, &buffer, opts); err != nil {
+		return err
 	}
 
-	file, err := parser.ParseFile(context.fset, srcFileName, nil, parser.ParseComments)
+	return ioutil.WriteFile(outFileName, buffer.Bytes(), 0666)
+}
+
+// CompileTo 与Compile相同，生成的代码写入w，出现错误时不写入任何内容
+func CompileTo(srcFileName string, w io.Writer, opts Options) error {
+	fileNames, err := GetModelFiles(filepath.Dir(srcFileName))
 
 	if err != nil {
 		return err
 	}
 
-	for _, decl := range file.Decls {
-		inst, ok := decl.(*ast.GenDecl)
-		if ok {
-			if inst.Tok == token.TYPE {
-				err := addTable(&context, inst)
+	var found bool
 
-				if err != nil {
-					return err
-				}
-			}
+	for _, fileName := range fileNames {
+		if sameFile(fileName, srcFileName) {
+			found = true
+			break
 		}
 	}
 
-	outWriter, err := os.Create(outFileName)
+	if !found {
+		fileNames = append(fileNames, srcFileName)
+	}
+
+	context, err := loadModelPackage(fileNames, opts)
 
 	if err != nil {
 		return err
 	}
 
-	defer outWriter.Close()
-	context.generator.writer = outWriter
+	var buffer bytes.Buffer
+
+	for _, file := range context.files {
+		if sameFile(context.fset.Position(file.Pos()).Filename, srcFileName) {
+			genModelFiles(context, &buffer, file)
+		}
+	}
+
+	if err := context.finish(opts.Warn); err != nil {
+		return err
+	}
+
+	_, err = buffer.WriteTo(w)
 
-	packName := file.Name.Name
+	return err
+}
 
-	var needSqlPackage bool
+// generatedHeader 生成文件的第一行，go工具与各种lint据此识别生成的代码
+const generatedHeader = "// Code generated by gosql. DO NOT EDIT."
 
-	for _, decl := range file.Decls {
-		inst, ok := decl.(*ast.FuncDecl)
+// IsGeneratedCode 判断src是否为gosql生成的代码
+func IsGeneratedCode(src []byte) bool {
+	return bytes.HasPrefix(src, []byte(generatedHeader+"\n")) || bytes.HasPrefix(src, []byte(generatedHeader+"\r\n"))
+}
 
-		if ok {
-			if inst.Name.Name == "init" {
-				for callExpr := range getCallExprList(inst) {
-					fun := callExpr.Fun.(*ast.SelectorExpr)
-
-					switch fun.Sel.Name {
-					case "SetPackageName":
-						lit, ok := callExpr.Args[0].(*ast.BasicLit)
-
-						if !ok ||
-							!(strings.HasPrefix(lit.Value, "\"") && strings.HasSuffix(lit.Value, "\"")) {
-							return newArgError(&context, callExpr)
-						}
-
-						packName = lit.Value[1 : len(lit.Value)-1]
-					default:
-						fmt.Println(newUnsupportedError(&context, callExpr))
-					}
-				}
-			} else {
-				for callExpr := range getCallExprList(inst) {
-					fun := callExpr.Fun.(*ast.SelectorExpr)
-
-					methodName := fun.Sel.Name
-
-					if strings.HasPrefix(methodName, "Insert") ||
-						strings.HasPrefix(methodName, "Delete") ||
-						strings.HasPrefix(methodName, "Update") {
-						needSqlPackage = true
-						break
-					}
+// genModelFiles 将files中定义的模型与函数生成到w，多个文件时合并为一个文件，输出经过gofmt格式化
+func genModelFiles(context *parseContext, w io.Writer, files ...*ast.File) {
+	var buffer bytes.Buffer
+
+	context.generator = newGenerator()
+	context.generator.writer = &buffer
+
+	var hasFunction, needSqlPackage, hasIter bool
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			inst, ok := decl.(*ast.FuncDecl)
+
+			if !ok || inst.Name.Name == "init" {
+				continue
+			}
+
+			if hasReturnType(inst, "ReturnRecordIter") {
+				hasIter = true
+			}
+
+			// 多语句函数与事务函数只使用sqlutil，不需要database/sql
+			if getStatementBlocks(inst) != nil || findSpecCall(inst, "Transactional") != nil {
+				hasFunction = true
+				continue
+			}
+
+			if findSpecCall(inst, "ExecProcedure") != nil {
+				hasFunction, needSqlPackage = true, true
+				continue
+			}
+
+			for callExpr := range getCallExprList(inst) {
+				methodName := callExpr.Fun.(*ast.SelectorExpr).Sel.Name
+
+				if strings.HasPrefix(methodName, "Select") ||
+					strings.HasPrefix(methodName, "Insert") ||
+					strings.HasPrefix(methodName, "Delete") ||
+					strings.HasPrefix(methodName, "Update") {
+					hasFunction, needSqlPackage = true, true
+					break
 				}
 			}
 		}
@@ -173,42 +290,67 @@ func Compile(srcFileName string, outFileName string, opts Options) error {
 
 	generator := context.generator
 
-	generator.writePackage(packName)
+	generator.write(generatedHeader)
+	generator.writeLine()
+	generator.writeLine()
+	generator.writePackage(context.packageName)
+
+	var imports []*ast.ImportSpec
 
-	imports := []*ast.ImportSpec{
-		newASTImportSpec("context", ""),
-		newASTImportSpec("github.com/YiCodes/gosql/sqlutil", ""),
+	// 只包含模型的文件不需要context、database/sql与sqlutil
+	if hasFunction {
+		imports = append(imports,
+			newASTImportSpec("context", ""),
+			newASTImportSpec("github.com/YiCodes/gosql/sqlutil", ""))
 	}
 
 	if needSqlPackage {
 		imports = append(imports, newASTImportSpec("database/sql", ""))
 	}
 
-	for _, p := range file.Imports {
-		switch getBasicLitValue(p.Path) {
-		case "context", "github.com/YiCodes/gosql/sqlcodegen":
-			continue
-		}
-
-		imports = append(imports, p)
+	if hasIter {
+		imports = append(imports, newASTImportSpec("iter", ""))
 	}
 
-	generator.writeImportList(imports...)
+	added := make(map[string]bool)
 
-	for _, decl := range file.Decls {
-		inst, ok := decl.(*ast.GenDecl)
-		if ok {
-			if inst.Tok == token.VAR {
-				err := addEntity(&context, inst)
-
-				if err != nil {
-					return err
+	for _, file := range files {
+		for _, p := range file.Imports {
+			switch getBasicLitValue(p.Path) {
+			case "context", "github.com/YiCodes/gosql/sqlcodegen":
+				continue
+			case "database/sql":
+				if needSqlPackage {
+					continue
 				}
+			case "iter":
+				if hasIter {
+					continue
+				}
+			}
+
+			key := p.Path.Value
+
+			if p.Name != nil {
+				key = p.Name.Name + " " + key
+			}
+
+			if !added[key] {
+				added[key] = true
+				imports = append(imports, p)
 			}
 		}
 	}
 
+	if len(imports) > 0 {
+		generator.writeImportList(imports...)
+	}
+
 	for _, t := range context.tables {
+		if !inFiles(context, t.source, files) {
+			continue
+		}
+
 		generator.writeDoc(t.source.Doc)
 		generator.write("type ")
 		generator.write(t.name)
@@ -245,10 +387,38 @@ func Compile(srcFileName string, outFileName string, opts Options) error {
 
 	generator.writeLine()
 
-	for _, decl := range file.Decls {
-		inst, ok := decl.(*ast.FuncDecl)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			inst, ok := decl.(*ast.FuncDecl)
+
+			if !ok || inst.Name.Name == "init" {
+				continue
+			}
+
+			if blocks := getStatementBlocks(inst); blocks != nil {
+				if err := genSequenceFunction(context, inst, blocks); err != nil {
+					context.addError(err)
+				}
+
+				continue
+			}
+
+			if !getFunctionReturnsResult(inst) {
+				if err := genTransactionalFunction(context, inst); err != nil {
+					context.addError(err)
+				}
+
+				continue
+			}
+
+			if findSpecCall(inst, "ExecProcedure") != nil {
+				if err := genProcedureFunction(context, inst); err != nil {
+					context.addError(err)
+				}
+
+				continue
+			}
 
-		if ok {
 		CheckSqlMethodLoop:
 			for callExpr := range getCallExprList(inst) {
 				fun := callExpr.Fun.(*ast.SelectorExpr)
@@ -257,59 +427,78 @@ func Compile(srcFileName string, outFileName string, opts Options) error {
 				var err error
 
 				if strings.HasPrefix(methodName, "Insert") {
-					err = genInsertFunction(&context, inst)
+					err = genInsertFunction(context, inst)
 				} else if strings.HasPrefix(methodName, "Select") {
-					err = genSelectFunction(&context, inst)
+					err = genSelectFunction(context, inst)
 				} else if strings.HasPrefix(methodName, "Delete") {
-					err = genDeleteFunction(&context, inst)
+					err = genDeleteFunction(context, inst)
 				} else if strings.HasPrefix(methodName, "Update") {
-					err = genUpdateFunction(&context, inst)
+					err = genUpdateFunction(context, inst)
 				} else {
 					continue
 				}
 
 				if err != nil {
-					return err
+					context.addError(err)
 				}
 				break CheckSqlMethodLoop
 			}
 		}
 	}
 
-	return nil
-}
+	// 出现错误时生成的代码不完整，不需要格式化
+	if context.diagnostics.HasErrors() {
+		return
+	}
 
-func getCallExprList(funcDecl *ast.FuncDecl) <-chan *ast.CallExpr {
-	channel := make(chan *ast.CallExpr)
+	src, err := format.Source(buffer.Bytes())
+
+	if err != nil {
+		context.addError(fmt.Errorf("format generated code: %v", err))
+		return
+	}
 
-	go func() {
-		defer close(channel)
+	w.Write(src)
+}
 
+// getCallExprList 返回函数体中形如pkg.Func(...)的调用语句，可以提前结束range循环
+func getCallExprList(funcDecl *ast.FuncDecl) iter.Seq[*ast.CallExpr] {
+	return func(yield func(*ast.CallExpr) bool) {
 		for _, stmt := range funcDecl.Body.List {
 			exprStmt, ok := stmt.(*ast.ExprStmt)
 
-			if ok {
-				callExpr, ok := exprStmt.X.(*ast.CallExpr)
+			if !ok {
+				continue
+			}
 
-				if ok {
-					_, ok := callExpr.Fun.(*ast.SelectorExpr)
+			callExpr, ok := exprStmt.X.(*ast.CallExpr)
 
-					if ok {
-						channel <- callExpr
-					}
-				}
+			if !ok {
+				continue
+			}
+
+			if _, ok := callExpr.Fun.(*ast.SelectorExpr); ok && !yield(callExpr) {
+				return
 			}
 		}
-	}()
+	}
+}
+
+func hasReturnType(funcDecl *ast.FuncDecl, returnType string) bool {
+	callExpr := findSpecCall(funcDecl, "SetReturnType")
+
+	if callExpr == nil || len(callExpr.Args) != 1 {
+		return false
+	}
+
+	selector, ok := callExpr.Args[0].(*ast.SelectorExpr)
 
-	return channel
+	return ok && selector.Sel.Name == returnType
 }
 
 func findSpecCall(funcDecl *ast.FuncDecl, callMethod string) *ast.CallExpr {
 	for callExpr := range getCallExprList(funcDecl) {
-		fun := callExpr.Fun.(*ast.SelectorExpr)
-
-		if fun.Sel.Name == callMethod {
+		if callExpr.Fun.(*ast.SelectorExpr).Sel.Name == callMethod {
 			return callExpr
 		}
 	}
@@ -320,8 +509,11 @@ func findSpecCall(funcDecl *ast.FuncDecl, callMethod string) *ast.CallExpr {
 func getFuncParamNames(funcDecl *ast.FuncDecl) map[string]int {
 	paramNames := make(map[string]int)
 
-	for i, arg := range funcDecl.Type.Params.List {
-		paramNames[arg.Names[0].Name] = i
+	// 同一类型的多个参数（如 a, b int64）共用一个Field，值为Field的下标
+	for i, field := range funcDecl.Type.Params.List {
+		for _, name := range field.Names {
+			paramNames[name.Name] = i
+		}
 	}
 
 	return paramNames
@@ -335,6 +527,13 @@ func genSelectFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
 	var whereExpr *ast.CallExpr
 	var returnTypeFlag ReturnType
 	var chanBufferSize int
+	var joinExprList []*ast.CallExpr
+	var resultTypeName string
+	var groupByExpr *ast.CallExpr
+	var havingExpr *ast.CallExpr
+	var limitExpr *ast.CallExpr
+	var offsetExpr *ast.CallExpr
+	var paginateExpr *ast.CallExpr
 
 	for callExpr := range getCallExprList(funcDecl) {
 		fun := callExpr.Fun.(*ast.SelectorExpr)
@@ -349,30 +548,41 @@ func genSelectFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
 			fromExpr = callExpr
 		case "Where":
 			whereExpr = callExpr
-		case "OrderBy", "OrderByDescending":
-			entity, ok := context.getEntityWithExpr(callExpr.Args[0])
-
-			if !ok {
+		case "GroupBy":
+			groupByExpr = callExpr
+		case "Having":
+			havingExpr = callExpr
+		case "Limit":
+			limitExpr = callExpr
+		case "Offset":
+			offsetExpr = callExpr
+		case "Paginate":
+			paginateExpr = callExpr
+		case "InnerJoin", "LeftJoin", "RightJoin", "FullJoin":
+			joinExprList = append(joinExprList, callExpr)
+		case "SetResultTypeName":
+			if len(callExpr.Args) != 1 {
 				return newArgError(context, callExpr)
 			}
 
-			colExpr, ok := (callExpr.Args[0].(*ast.SelectorExpr))
+			lit, ok := callExpr.Args[0].(*ast.BasicLit)
+
+			if !ok || lit.Kind != token.STRING {
+				return newArgError(context, callExpr)
+			}
 
-			if !ok {
+			resultTypeName = getBasicLitValue(lit)
+		case "OrderBy", "OrderByDescending":
+			if len(callExpr.Args) != 1 {
 				return newArgError(context, callExpr)
 			}
 
-			col, ok := entity.getColumn(colExpr.Sel.Name)
+			sqlColExpr, err := astToSQLSelectExpression(context, callExpr.Args[0])
 
-			if !ok {
+			if err != nil {
 				return newArgError(context, callExpr)
 			}
 
-			sqlColExpr := &SQLColumnExpression{}
-			sqlColExpr.columnName = col.columnName
-			sqlColExpr.source = col
-			sqlColExpr.tableName = entity.tableName
-
 			isDesc := fun.Sel.Name == "OrderByDescending"
 
 			sqlOrderExpr := &SQLOrderExpression{column: sqlColExpr, isDescending: isDesc}
@@ -380,94 +590,53 @@ func genSelectFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
 			orderByList = append(orderByList, sqlOrderExpr)
 
 		case "SetReturnType":
-			if len(callExpr.Args) != 1 {
-				return newArgError(context, callExpr)
-			}
+			t, err := astToReturnType(context, callExpr)
 
-			c := (callExpr.Args[0].(*ast.SelectorExpr)).Sel.Name
-
-			switch c {
-			case "ReturnDefault":
-				returnTypeFlag = ReturnDefault
-			case "ReturnExecResult":
-				returnTypeFlag = ReturnExecResult
-			case "ReturnScalar":
-				returnTypeFlag = ReturnScalar
-			case "ReturnScalarSet":
-				returnTypeFlag = ReturnScalarSet
-			case "ReturnRecord":
-				returnTypeFlag = ReturnRecord
-			case "ReturnRecordSet":
-				returnTypeFlag = ReturnRecordSet
-			case "ReturnRecordChannel":
-				returnTypeFlag = ReturnRecordChannel
-			default:
-				return newArgError(context, callExpr)
-			}
-		case "SetChannelBufferSize":
-			if len(callExpr.Args) != 1 {
-				return newArgError(context, callExpr)
+			if err != nil {
+				return err
 			}
 
-			lit, ok := callExpr.Args[0].(*ast.BasicLit)
+			returnTypeFlag = t
+		case "SetChannelBufferSize":
+			size, err := astToChannelBufferSize(context, callExpr)
 
-			if !ok {
-				return newArgError(context, callExpr)
+			if err != nil {
+				return err
 			}
 
-			chanBufferSize, _ = strconv.Atoi(lit.Value)
+			chanBufferSize = size
 		}
 	}
 
-	selectStmt := &SQLSelectStatement{}
-	selectStmt.orderByList = orderByList
-
-	if isSelectAll {
-		entity, ok := context.getEntityWithExpr(selectExpr.Args[0])
-
-		if !ok {
-			return newArgError(context, selectExpr)
-		}
-
-		selectStmt = tableToSelectStatement(context.sqlBuilder, selectStmt, entity)
-	} else {
-		for _, expr := range selectExpr.Args {
-			entity, ok := context.getEntityWithExpr(expr)
-
-			if !ok {
-				return newArgError(context, selectExpr)
-			}
-
-			colExpr, ok := expr.(*ast.SelectorExpr)
-
-			if !ok {
-				return newArgError(context, selectExpr)
-			}
-
-			sqlColExpr := &SQLColumnExpression{}
-			sqlColExpr.tableName = entity.tableName
+	selectStmt, err := astToSQLSelectStatement(context, selectExpr, isSelectAll)
 
-			col, ok := entity.getColumn(colExpr.Sel.Name)
+	if err != nil {
+		return err
+	}
 
-			if !ok {
-				return newArgError(context, selectExpr)
-			}
+	selectStmt.orderByList = orderByList
 
-			sqlColExpr.source = col
-			sqlColExpr.columnName = col.columnName
+	if fromExpr != nil {
+		tableName, err := getTableNameWithExpr(context, fromExpr)
 
-			selectStmt.selectList = append(selectStmt.selectList, sqlColExpr)
+		if err != nil {
+			return err
 		}
+
+		selectStmt.table = tableName
+		selectStmt.alias = fromExpr.Args[0].(*ast.Ident).Name
+	} else if len(joinExprList) > 0 {
+		return newArgError(context, joinExprList[0])
 	}
 
-	if fromExpr != nil {
-		tableName, err := getTableNameWithExpr(context, fromExpr)
+	for _, joinExpr := range joinExprList {
+		sqlJoinExpr, err := astToSQLJoinExpression(context, funcDecl, joinExpr)
 
 		if err != nil {
 			return err
 		}
 
-		selectStmt.table = tableName
+		selectStmt.joinList = append(selectStmt.joinList, sqlJoinExpr)
 	}
 
 	if sqlWhereExpr, err := astToSQLWhereExpression(context, funcDecl, whereExpr); err == nil {
@@ -476,233 +645,616 @@ func genSelectFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
 		return err
 	}
 
-	if returnTypeFlag == ReturnDefault {
-		returnTypeFlag = ReturnRecordSet
-	}
+	if groupByExpr != nil {
+		for _, expr := range groupByExpr.Args {
+			sqlColExpr, ok := context.getColumnWithExpr(expr)
 
-	entity, ok := context.getEntityWithExpr(selectExpr.Args[0])
+			if !ok {
+				return newArgError(context, groupByExpr)
+			}
 
-	if !ok {
-		return newArgError(context, selectExpr)
+			selectStmt.groupByList = append(selectStmt.groupByList, sqlColExpr)
+		}
 	}
 
-	var funcReturnList []*ast.Field
-	var returnElementType string
+	if sqlHavingExpr, err := astToSQLWhereExpression(context, funcDecl, havingExpr); err == nil {
+		if isDynamicExpression(sqlHavingExpr) {
+			return newUnsupportedError(context, havingExpr)
+		}
 
-	switch returnTypeFlag {
-	case ReturnRecordSet:
-		funcReturnList = append(funcReturnList,
-			newASTField(newASTRefExpr("[]*"+entity.name), ""))
-		returnElementType = entity.name
-	case ReturnRecordChannel:
-		funcReturnList = append(funcReturnList,
-			newASTField(newASTRefExpr("<-chan *"+entity.name), ""))
-		returnElementType = entity.name
-	case ReturnRecord:
-		funcReturnList = append(funcReturnList,
-			newASTField(newASTRefExpr("*"+entity.name), ""))
-		returnElementType = entity.name
-	case ReturnScalar:
-		sqlColExpr, ok := selectStmt.getFirstColumnExpression()
+		selectStmt.having = sqlHavingExpr
+	} else {
+		return err
+	}
 
-		if !ok {
-			return newArgError(context, selectExpr)
+	if paginateExpr != nil {
+		if limitExpr != nil || offsetExpr != nil || len(paginateExpr.Args) != 2 {
+			return newArgError(context, paginateExpr)
 		}
 
-		funcReturnList = append(funcReturnList,
-			newASTField(newASTRefExpr(sqlColExpr.source.sysType), ""))
-		returnElementType = sqlColExpr.source.sysType
-	case ReturnScalarSet:
-		sqlColExpr, ok := selectStmt.getFirstColumnExpression()
+		if returnTypeFlag != ReturnDefault && returnTypeFlag != ReturnRecordSet {
+			return newArgError(context, paginateExpr)
+		}
 
-		if !ok {
-			return newArgError(context, selectExpr)
+		for _, arg := range paginateExpr.Args {
+			if _, err := astToSQLLimitExpression(context, funcDecl, arg); err != nil {
+				return newArgError(context, paginateExpr)
+			}
 		}
 
-		funcReturnList = append(funcReturnList,
-			newASTField(newASTRefExpr("[]*"+sqlColExpr.source.sysType), ""))
-		returnElementType = sqlColExpr.source.sysType
+		selectStmt.limit = &SQLParameterExpression{name: "result.Size"}
+		selectStmt.offset = &SQLParameterExpression{name: "result.Offset()"}
 	}
 
-	if returnTypeFlag == ReturnRecordChannel {
-		funcReturnList = append(funcReturnList,
-			newASTField(newASTRefExpr("context.CancelFunc"), ""))
+	if limitExpr != nil {
+		if len(limitExpr.Args) != 1 {
+			return newArgError(context, limitExpr)
+		}
+
+		sqlExpr, err := astToSQLLimitExpression(context, funcDecl, limitExpr.Args[0])
+
+		if err != nil {
+			return newArgError(context, limitExpr)
+		}
+
+		selectStmt.limit = sqlExpr
 	}
 
-	context.sqlBuilder.Reset()
-	context.sqlBuilder.WriteSelectStatement(selectStmt)
-	sqlText := context.sqlBuilder.String()
+	if offsetExpr != nil {
+		if len(offsetExpr.Args) != 1 {
+			return newArgError(context, offsetExpr)
+		}
 
-	genMethodBegin(context, funcDecl.Name.Name, funcDecl.Type.Params.List, funcReturnList, funcDecl.Doc)
-	generator := context.generator
+		sqlExpr, err := astToSQLLimitExpression(context, funcDecl, offsetExpr.Args[0])
 
-	generator.writeConstDeclaration("query", sqlText)
-	generator.write("rows, err := db.QueryContext(context.Background(), query")
+		if err != nil {
+			return newArgError(context, offsetExpr)
+		}
 
-	for _, p := range getSelectStmtSqlParamList(selectStmt) {
-		generator.write(", ")
-		generator.write(p.name)
+		selectStmt.offset = sqlExpr
 	}
 
-	generator.writeLine(")")
+	if returnTypeFlag == ReturnDefault {
+		returnTypeFlag = ReturnRecordSet
+	}
 
-	generator.write("if err != nil")
-	generator.beginBlock()
+	result, err := getSelectResultType(context, funcDecl, selectStmt, resultTypeName)
 
-	if returnTypeFlag == ReturnRecordChannel {
-		generator.writeLine("return nil, nil, err")
-	} else {
-		generator.writeLine("return nil, err")
+	if err != nil {
+		return err
 	}
 
-	generator.endBlock()
+	funcReturnList, returnElementType, err := getRowsReturnList(context, selectExpr, returnTypeFlag, result, paginateExpr != nil)
 
-	if returnTypeFlag != ReturnRecordChannel {
-		generator.writeLine("defer rows.Close()")
+	if err != nil {
+		return err
 	}
 
-	switch returnTypeFlag {
-	case ReturnRecordSet:
-		generator.writeVarDeclaration("result", funcReturnList[0].Type, false)
+	query, err := buildSQLQuery(context, funcDecl, "query", selectStmt.where, getSelectStmtSqlParamList(selectStmt),
+		func(builder SQLBuilder) { builder.WriteSelectStatement(selectStmt) })
 
-		generator.write("for rows.Next()")
-		generator.beginBlock()
+	if err != nil {
+		return err
+	}
 
-		generator.writeVarDeclaration("o", newASTRefExpr(returnElementType), true)
+	var countQuery *sqlQuery
 
-		generator.write("rows.Scan(")
+	if paginateExpr != nil {
+		countStmt := *selectStmt
+		countStmt.limit = nil
+		countStmt.offset = nil
 
-		for i, selectExpr := range selectStmt.selectList {
-			if i > 0 {
-				generator.write(", ")
-			}
+		countQuery, err = buildSQLQuery(context, funcDecl, "countQuery", selectStmt.where, getSelectStmtSqlParamList(&countStmt),
+			func(builder SQLBuilder) { writeCountStatement(builder, selectStmt) })
 
-			generator.write("&o.")
-			generator.write((selectExpr.(*SQLColumnExpression)).source.name)
+		if err != nil {
+			return err
 		}
+	}
 
-		generator.writeLine(")")
-		generator.writeLine("result = append(result, o)")
+	if err := genRowsResultType(context, funcDecl, returnTypeFlag, result); err != nil {
+		return err
+	}
 
-		generator.endBlock()
+	genRowsMethodBegin(context, funcDecl, returnTypeFlag, funcReturnList)
 
-		generator.writeLine("return result, nil")
+	if paginateExpr != nil {
+		genPaginateQuery(context, countQuery, paginateExpr, returnElementType)
+	}
 
-	case ReturnRecord:
-		generator.write("if rows.Next()")
-		generator.beginBlock()
+	genQueryDeclaration(context, query)
+	genQueryRows(context, query, returnTypeFlag, result, returnElementType, chanBufferSize, paginateExpr != nil)
+	genMethodEnd(context)
 
-		generator.writeVarDeclaration("o", newASTRefExpr(returnElementType), true)
+	return nil
+}
 
-		generator.write("rows.Scan(")
+func getRowsReturnList(context *parseContext, node ast.Node, returnTypeFlag ReturnType, result *resultType, isPaginate bool) ([]*ast.Field, string, error) {
+	var funcReturnList []*ast.Field
+	var returnElementType string
 
-		for i, selectExpr := range selectStmt.selectList {
-			if i > 0 {
-				generator.write(", ")
-			}
+	switch returnTypeFlag {
+	case ReturnRecordSet:
+		if isPaginate {
+			funcReturnList = append(funcReturnList,
+				newASTField(newASTRefExpr("*sqlutil.Page[*"+result.name+"]"), ""))
+		} else {
+			funcReturnList = append(funcReturnList,
+				newASTField(newASTRefExpr("[]*"+result.name), ""))
+		}
+		returnElementType = result.name
+	case ReturnRecordChannel:
+		funcReturnList = append(funcReturnList,
+			newASTField(newASTRefExpr("*sqlutil.Stream[*"+result.name+"]"), ""))
+		returnElementType = result.name
+	case ReturnRecordIter:
+		funcReturnList = append(funcReturnList,
+			newASTField(newASTRefExpr("iter.Seq2[*"+result.name+", error]"), ""))
+		returnElementType = result.name
+	case ReturnRecord:
+		funcReturnList = append(funcReturnList,
+			newASTField(newASTRefExpr("*"+result.name), ""))
+		returnElementType = result.name
+	case ReturnScalar:
+		if len(result.fields) == 0 {
+			return nil, "", newArgError(context, node)
+		}
 
-			generator.write("&o.")
-			generator.write((selectExpr.(*SQLColumnExpression)).source.name)
+		funcReturnList = append(funcReturnList,
+			newASTField(newASTRefExpr(result.fields[0].sysType), ""))
+		returnElementType = result.fields[0].sysType
+	case ReturnScalarSet:
+		if len(result.fields) == 0 {
+			return nil, "", newArgError(context, node)
 		}
 
-		generator.writeLine(")")
-		generator.writeLine("return o, nil")
+		funcReturnList = append(funcReturnList,
+			newASTField(newASTRefExpr("[]*"+result.fields[0].sysType), ""))
+		returnElementType = result.fields[0].sysType
+	default:
+		return nil, "", newArgError(context, node)
+	}
 
-		generator.endBlock()
+	return funcReturnList, returnElementType, nil
+}
+
+// genRowsMethodBegin 生成读取记录的函数的开始，ReturnRecordIter的错误在迭代中返回，函数不返回error
+func genRowsMethodBegin(context *parseContext, funcDecl *ast.FuncDecl, returnTypeFlag ReturnType, returnList []*ast.Field) {
+	if returnTypeFlag == ReturnRecordIter {
+		genFuncBegin(context, funcDecl.Name.Name, funcDecl.Type.Params.List, returnList, funcDecl.Doc)
+	} else {
+		genMethodBegin(context, funcDecl.Name.Name, funcDecl.Type.Params.List, returnList, funcDecl.Doc)
+	}
+}
 
-		generator.writeLine("return nil, nil")
+func genRowsResultType(context *parseContext, funcDecl *ast.FuncDecl, returnTypeFlag ReturnType, result *resultType) error {
+	if returnTypeFlag == ReturnScalar || returnTypeFlag == ReturnScalarSet {
+		return nil
+	}
+
+	isNewType, err := registerResultType(context, funcDecl, result)
+
+	if err != nil {
+		return err
+	}
+
+	if isNewType {
+		genResultType(context, result)
+	}
+
+	return nil
+}
+
+// genQueryRows 使用sqlutil的QueryOne、QueryAll、QueryStream、QueryIter执行查询，生成读取一行的函数
+func genQueryRows(context *parseContext, query *sqlQuery, returnTypeFlag ReturnType, result *resultType, returnElementType string, chanBufferSize int, isPaginate bool) {
+	generator := context.generator
+
+	function := "sqlutil.QueryAll"
+	scanType := "*" + returnElementType
+
+	switch returnTypeFlag {
+	case ReturnRecord:
+		function = "sqlutil.QueryOne"
 	case ReturnScalar:
-		generator.write("if rows.Next()")
-		generator.beginBlock()
-		generator.writeVarDeclaration("o", funcReturnList[0].Type, true)
+		function = "sqlutil.QueryOne"
+		scanType = returnElementType
+	case ReturnRecordChannel:
+		function = "sqlutil.QueryStream"
+	case ReturnRecordIter:
+		function = "sqlutil.QueryIter"
+	}
 
-		generator.writeLine("rows.Scan(o)")
-		generator.writeLine("return o, nil")
+	if isPaginate {
+		generator.write("items, err := ")
+	} else {
+		generator.write("return ")
+	}
 
-		generator.endBlock()
-		generator.writeLine("return nil, nil")
+	generator.write(function + "(" + getContextArg(context) + ", db, " + getQueryTextCode(query))
 
-	case ReturnScalarSet:
-		generator.writeVarDeclaration("result", funcReturnList[0].Type, false)
+	if returnTypeFlag == ReturnRecordChannel {
+		generator.write(", " + strconv.Itoa(chanBufferSize))
+	}
 
-		generator.write("for rows.Next()")
-		generator.beginBlock()
+	generator.write(", func(rows *sql.Rows) (" + scanType + ", error)")
+	generator.beginBlock()
 
+	switch returnTypeFlag {
+	case ReturnScalar:
+		generator.writeVarDeclaration("o", newASTRefExpr(returnElementType), false)
+		generator.writeLine("err := ", getScanValueCode("&o", result.fields[0].scanNull))
+		generator.writeLine("return o, err")
+	case ReturnScalarSet:
+		generator.writeVarDeclaration("o", newASTRefExpr(returnElementType), true)
+		generator.writeLine("return o, ", getScanValueCode("o", result.fields[0].scanNull))
+	default:
 		generator.writeVarDeclaration("o", newASTRefExpr(returnElementType), true)
+		generator.writeLine("return o, ", getScanRecordCode(result))
+	}
 
-		generator.writeLine("rows.Scan(o)")
-		generator.writeLine("result = append(result, o)")
+	generator.endBlock(getQueryArgsCode(query), ")")
 
+	if isPaginate {
+		generator.write("if err != nil")
+		generator.beginBlock()
+		generator.writeLine("return nil, err")
 		generator.endBlock()
+		generator.writeLine("result.Items = items")
 		generator.writeLine("return result, nil")
+	}
+}
 
-	case ReturnRecordChannel:
-		if chanBufferSize > 0 {
-			generator.writeLine("channel := make(chan *", returnElementType, ", ", strconv.Itoa(chanBufferSize), ")")
+func astToReturnType(context *parseContext, callExpr *ast.CallExpr) (ReturnType, error) {
+	if len(callExpr.Args) != 1 {
+		return ReturnDefault, newArgError(context, callExpr)
+	}
+
+	selector, ok := callExpr.Args[0].(*ast.SelectorExpr)
+
+	if !ok {
+		return ReturnDefault, newArgError(context, callExpr)
+	}
+
+	switch selector.Sel.Name {
+	case "ReturnDefault":
+		return ReturnDefault, nil
+	case "ReturnExecResult":
+		return ReturnExecResult, nil
+	case "ReturnScalar":
+		return ReturnScalar, nil
+	case "ReturnScalarSet":
+		return ReturnScalarSet, nil
+	case "ReturnRecord":
+		return ReturnRecord, nil
+	case "ReturnRecordSet":
+		return ReturnRecordSet, nil
+	case "ReturnRecordChannel":
+		return ReturnRecordChannel, nil
+	case "ReturnRecordIter":
+		return ReturnRecordIter, nil
+	}
+
+	return ReturnDefault, newArgError(context, callExpr)
+}
+
+func astToChannelBufferSize(context *parseContext, callExpr *ast.CallExpr) (int, error) {
+	if len(callExpr.Args) != 1 {
+		return 0, newArgError(context, callExpr)
+	}
+
+	lit, ok := callExpr.Args[0].(*ast.BasicLit)
+
+	if !ok {
+		return 0, newArgError(context, callExpr)
+	}
+
+	size, _ := strconv.Atoi(lit.Value)
+
+	return size, nil
+}
+
+func astToSQLSelectStatement(context *parseContext, selectExpr *ast.CallExpr, isSelectAll bool) (*SQLSelectStatement, error) {
+	selectStmt := &SQLSelectStatement{}
+
+	if selectExpr == nil {
+		return selectStmt, nil
+	}
+
+	if isSelectAll {
+		if len(selectExpr.Args) != 1 {
+			return nil, newArgError(context, selectExpr)
+		}
+
+		entityName, ok := selectExpr.Args[0].(*ast.Ident)
+
+		if !ok {
+			return nil, newArgError(context, selectExpr)
+		}
+
+		entity, ok := context.getEntityWithExpr(entityName)
+
+		if !ok {
+			return nil, newArgError(context, selectExpr)
+		}
+
+		return tableToSelectStatement(context.sqlBuilder, selectStmt, entity, entityName.Name), nil
+	}
+
+	for _, expr := range selectExpr.Args {
+		sqlExpr, err := astToSQLSelectExpression(context, expr)
+
+		if err != nil {
+			return nil, newArgError(context, selectExpr)
+		}
+
+		selectStmt.selectList = append(selectStmt.selectList, sqlExpr)
+	}
+
+	return selectStmt, nil
+}
+
+func genPaginateQuery(context *parseContext, countQuery *sqlQuery, paginateExpr *ast.CallExpr, elementType string) {
+	generator := context.generator
+
+	genQueryDeclaration(context, countQuery)
+	generator.write("result := sqlutil.NewPage[*")
+	generator.write(elementType)
+	generator.write("](int(")
+	generator.write(getExprCode(paginateExpr.Args[0]))
+	generator.write("), int(")
+	generator.write(getExprCode(paginateExpr.Args[1]))
+	generator.writeLine("))")
+
+	generator.write("total, err := sqlutil.QueryOne(" + getContextArg(context) + ", db, " + getQueryTextCode(countQuery))
+	generator.write(", func(rows *sql.Rows) (int64, error)")
+	generator.beginBlock()
+	generator.writeLine("var o int64")
+	generator.writeLine("err := rows.Scan(&o)")
+	generator.writeLine("return o, err")
+	generator.endBlock(getQueryArgsCode(countQuery), ")")
+	generator.write("if err != nil")
+	generator.beginBlock()
+	generator.writeLine("return nil, err")
+	generator.endBlock()
+	generator.writeLine("result.Total = total")
+}
+
+func getExprCode(expr ast.Expr) string {
+	switch inst := expr.(type) {
+	case *ast.Ident:
+		return inst.Name
+	case *ast.BasicLit:
+		return inst.Value
+	}
+
+	return ""
+}
+
+func getScanRecordCode(result *resultType) string {
+	var code strings.Builder
+
+	code.WriteString("rows.Scan(")
+
+	for i, field := range result.fields {
+		if i > 0 {
+			code.WriteString(", ")
+		}
+
+		if field.scanNull {
+			code.WriteString("sqlutil.ScanNullable(&o." + field.name + ")")
 		} else {
-			generator.writeLine("channel := make(chan *", returnElementType, ")")
+			code.WriteString("&o." + field.name)
 		}
+	}
 
-		generator.writeLine("ctx, cancel := context.WithCancel(context.Background())")
-		generator.write("go func()")
-		generator.beginBlock()
-		generator.writeLine("defer close(channel)")
-		generator.writeLine("defer rows.Close()")
-		generator.write("for rows.Next()")
-		generator.beginBlock()
+	code.WriteString(")")
 
-		generator.writeVarDeclaration("o", newASTRefExpr(returnElementType), true)
+	return code.String()
+}
+
+func getScanValueCode(dest string, scanNull bool) string {
+	if scanNull {
+		return "rows.Scan(sqlutil.ScanNullable(" + dest + "))"
+	}
+
+	return "rows.Scan(" + dest + ")"
+}
 
-		generator.write("rows.Scan(")
+func genExec(context *parseContext, query *sqlQuery) {
+	context.generator.writeLine("return ", getExecCode(context, query, "db"))
+}
+
+func getExecCode(context *parseContext, query *sqlQuery, dbName string) string {
+	return "sqlutil.Exec(" + getContextArg(context) + ", " + dbName + ", " + getQueryTextCode(query) + getQueryArgsCode(query) + ")"
+}
+
+func getSelectResultType(context *parseContext, funcDecl *ast.FuncDecl, stmt *SQLSelectStatement, resultTypeName string) (*resultType, error) {
+	result := &resultType{}
+	var source *table
+	var altNames []string
+
+	outerEntities := getOuterJoinEntities(stmt)
+
+	for i, expr := range stmt.selectList {
+		var field *resultField
+		var altName string
+
+		switch inst := expr.(type) {
+		case *SQLColumnExpression:
+			if i == 0 {
+				source = inst.source.table
+			} else if source != inst.source.table {
+				source = nil
+			}
 
-		for i, selectExpr := range selectStmt.selectList {
-			if i > 0 {
-				generator.write(", ")
+			field = &resultField{name: inst.source.name, sysType: inst.source.sysType}
+			field.scanNull = (inst.source.isNull || outerEntities[inst.entityName]) && !isNullableTypeName(inst.source.sysType)
+			altName = inst.source.table.shortName() + inst.source.name
+
+		case *SQLAggregateExpression:
+			source = nil
+			field = &resultField{name: inst.function, sysType: inst.getResultType()}
+			altName = inst.function
+
+			if inst.column != nil {
+				field.name += inst.column.source.name
+				altName += inst.column.source.table.shortName() + inst.column.source.name
 			}
 
-			generator.write("&o.")
-			generator.write((selectExpr.(*SQLColumnExpression)).source.name)
+		default:
+			return nil, newArgError(context, funcDecl)
 		}
 
-		generator.writeLine(")")
+		result.fields = append(result.fields, field)
+		altNames = append(altNames, altName)
+	}
+
+	if source != nil && resultTypeName == "" {
+		result.name = source.name
+		return result, nil
+	}
+
+	if resultTypeName == "" {
+		resultTypeName = funcDecl.Name.Name + "Result"
+	}
 
-		generator.writeLine("select {")
-		generator.writeLine("case <-ctx.Done(): ")
-		generator.indentLevel++
-		generator.writeLine("return")
-		generator.indentLevel--
-		generator.writeLine("case channel <- o:")
-		generator.writeLine("}")
+	result.name = resultTypeName
+	result.isGenType = true
 
-		generator.endBlock()
-		generator.endBlock("()")
+	fieldNames := make(map[string]int)
 
-		generator.writeLine("return channel, cancel, nil")
+	for _, f := range result.fields {
+		fieldNames[f.name]++
 	}
 
-	genMethodEnd(context)
+	for i, f := range result.fields {
+		if fieldNames[f.name] > 1 {
+			f.name = altNames[i]
+		}
+	}
 
-	return nil
+	return result, nil
+}
+
+// getOuterJoinEntities 返回外连接中可能没有匹配记录的实体，这些实体的列在结果中可能为NULL：
+// LEFT JOIN的右侧，RIGHT JOIN的左侧（之前的所有实体），FULL JOIN的两侧
+func getOuterJoinEntities(stmt *SQLSelectStatement) map[string]bool {
+	result := make(map[string]bool)
+	previous := []string{stmt.alias}
+
+	for _, join := range stmt.joinList {
+		switch join.joinType {
+		case "LEFT JOIN":
+			result[join.alias] = true
+		case "RIGHT JOIN":
+			for _, name := range previous {
+				result[name] = true
+			}
+		case "FULL JOIN":
+			result[join.alias] = true
+
+			for _, name := range previous {
+				result[name] = true
+			}
+		}
+
+		previous = append(previous, join.alias)
+	}
+
+	return result
+}
+
+func registerResultType(context *parseContext, funcDecl *ast.FuncDecl, result *resultType) (bool, error) {
+	if !result.isGenType {
+		return false, nil
+	}
+
+	if r, ok := context.resultTypes[result.name]; ok {
+		if !r.equals(result) {
+			return false, newTypeDefError(context, result.name, funcDecl)
+		}
+
+		return false, nil
+	}
+
+	context.resultTypes[result.name] = result
+
+	return true, nil
+}
+
+// isNullableTypeName 判断字段类型能否表示NULL，如指针与sql.Null类型
+func isNullableTypeName(sysType string) bool {
+	if strings.HasPrefix(sysType, "*") || strings.HasPrefix(sysType, "sql.Null") {
+		return true
+	}
+
+	return strings.HasPrefix(sysType[strings.LastIndex(sysType, ".")+1:], "Null")
+}
+
+func genResultType(context *parseContext, result *resultType) {
+	generator := context.generator
+
+	generator.write("type ")
+	generator.write(result.name)
+	generator.write(" struct")
+	generator.beginBlock()
+
+	var maxFieldSize = 0
+
+	for _, f := range result.fields {
+		if len(f.name) > maxFieldSize {
+			maxFieldSize = len(f.name)
+		}
+	}
+
+	for _, f := range result.fields {
+		generator.write(f.name)
+		generator.write(strings.Repeat(" ", maxFieldSize-len(f.name)))
+		generator.write(" ")
+		generator.writeLine(f.sysType)
+	}
+
+	generator.endBlock()
 }
 
 func genMethodEnd(context *parseContext) {
 	context.generator.endBlock()
 }
 
-func genMethodBegin(context *parseContext, funcName string, paramList []*ast.Field, returnList []*ast.Field, doc *ast.CommentGroup) {
-	generator := context.generator
+func getContextParamList(context *parseContext) []*ast.Field {
+	if !context.useContext {
+		return nil
+	}
+
+	return []*ast.Field{newASTField(newASTRefExpr("context.Context"), "ctx")}
+}
 
-	paramListCopy := make([]*ast.Field, len(paramList)+1)
+func getContextArg(context *parseContext) string {
+	if context.useContext {
+		return "ctx"
+	}
 
-	paramListCopy[0] = newASTField(newASTRefExpr("sqlutil.DbObject"), "db")
-	copy(paramListCopy[1:], paramList)
+	return "context.Background()"
+}
 
+func genMethodBegin(context *parseContext, funcName string, paramList []*ast.Field, returnList []*ast.Field, doc *ast.CommentGroup) {
 	returnListCopy := make([]*ast.Field, len(returnList), len(returnList)+1)
 	copy(returnListCopy, returnList)
 	returnListCopy = append(returnListCopy, newASTField(newASTRefExpr("error"), ""))
 
+	genFuncBegin(context, funcName, paramList, returnListCopy, doc)
+}
+
+// genFuncBegin 与genMethodBegin相同，但不在returnList之后追加error
+func genFuncBegin(context *parseContext, funcName string, paramList []*ast.Field, returnList []*ast.Field, doc *ast.CommentGroup) {
+	generator := context.generator
+
+	paramListCopy := getContextParamList(context)
+	paramListCopy = append(paramListCopy, newASTField(newASTRefExpr("sqlutil.DbObject"), "db"))
+	paramListCopy = append(paramListCopy, paramList...)
+
 	generator.writeDoc(doc)
-	generator.beginFunc(funcName, paramListCopy, returnListCopy)
+	generator.beginFunc(funcName, paramListCopy, returnList)
 }
 
 func astToSQLExpression(expr ast.Expr, context *parseContext, paramNames map[string]int) (SQLExpression, error) {
@@ -712,29 +1264,41 @@ func astToSQLExpression(expr ast.Expr, context *parseContext, paramNames map[str
 		if ok {
 			return &SQLParameterExpression{name: inst.Name}, nil
 		}
-		return nil, errors.New("")
+		if isNilIdent(inst) {
+			return &SQLLiteralExpression{value: "NULL"}, nil
+		}
+		return nil, context.newError(inst, "%s is not a parameter of the function", inst.Name)
 
 	case *ast.BasicLit:
 		return &SQLLiteralExpression{value: inst.Value}, nil
 
 	case *ast.SelectorExpr:
-		entity, ok := context.getEntityWithExpr(inst)
+		sqlColExpr, ok := context.getColumnWithExpr(inst)
 		if ok {
-			sqlColExpr := &SQLColumnExpression{}
+			return sqlColExpr, nil
+		}
+		return nil, context.newError(inst, "%s is not a column of an entity", types.ExprString(inst))
 
-			col, ok := entity.getColumn(inst.Sel.Name)
+	case *ast.CallExpr:
+		if fun, ok := inst.Fun.(*ast.SelectorExpr); ok && fun.Sel.Name == "Optional" {
+			if len(inst.Args) != 1 {
+				return nil, newArgError(context, inst)
+			}
 
-			if !ok {
-				return nil, errors.New("")
+			sqlExpr, err := astToSQLExpression(inst.Args[0], context, paramNames)
+
+			if err != nil {
+				return nil, err
 			}
 
-			sqlColExpr.source = col
-			sqlColExpr.columnName = col.columnName
-			sqlColExpr.tableName = entity.tableName
+			return &SQLOptionalExpression{target: sqlExpr}, nil
+		}
 
-			return sqlColExpr, nil
+		if sqlExpr, ok, err := astToSQLPredicateExpression(context, inst, paramNames); ok {
+			return sqlExpr, err
 		}
-		return nil, errors.New("")
+
+		return astToSQLAggregateExpression(context, inst)
 
 	case *ast.ParenExpr:
 		sqlExpr, err := astToSQLExpression(inst.X, context, paramNames)
@@ -746,6 +1310,28 @@ func astToSQLExpression(expr ast.Expr, context *parseContext, paramNames map[str
 		return &SQLParenthesisExpression{target: sqlExpr}, nil
 
 	case *ast.BinaryExpr:
+		if inst.Op == token.EQL || inst.Op == token.NEQ {
+			if isNilIdent(inst.Y) || isNilIdent(inst.X) {
+				column := inst.X
+
+				if isNilIdent(inst.X) {
+					column = inst.Y
+				}
+
+				sqlColExpr, ok := context.getColumnWithExpr(column)
+
+				if !ok {
+					return nil, newArgError(context, inst)
+				}
+
+				return &SQLIsNullExpression{column: sqlColExpr, not: inst.Op == token.NEQ}, nil
+			}
+
+			if sqlExpr, ok := astToSQLNullableCompareExpression(context, inst, paramNames); ok {
+				return sqlExpr, nil
+			}
+		}
+
 		sqlBinExpr := &SQLBinaryExpression{}
 		sqlExpr, err := astToSQLExpression(inst.X, context, paramNames)
 
@@ -766,7 +1352,156 @@ func astToSQLExpression(expr ast.Expr, context *parseContext, paramNames map[str
 		return sqlBinExpr, nil
 	}
 
-	return nil, errors.New("")
+	return nil, newUnsupportedError(context, expr)
+}
+
+func isNilIdent(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+
+	return ok && ident.Name == "nil"
+}
+
+// astToSQLNullableCompareExpression 列与指针或sql.Null类型的参数比较，参数为nil时在运行时生成 IS NULL
+func astToSQLNullableCompareExpression(context *parseContext, expr *ast.BinaryExpr, paramNames map[string]int) (SQLExpression, bool) {
+	column, param := expr.X, expr.Y
+
+	if _, ok := param.(*ast.Ident); !ok {
+		column, param = expr.Y, expr.X
+	}
+
+	ident, ok := param.(*ast.Ident)
+
+	if !ok || !isNullableParam(ident, paramNames) {
+		return nil, false
+	}
+
+	sqlColExpr, ok := context.getColumnWithExpr(column)
+
+	if !ok {
+		return nil, false
+	}
+
+	return &SQLNullableCompareExpression{
+		column: sqlColExpr,
+		param:  &SQLParameterExpression{name: ident.Name},
+		not:    expr.Op == token.NEQ,
+	}, true
+}
+
+func isNullableParam(ident *ast.Ident, paramNames map[string]int) bool {
+	if _, ok := paramNames[ident.Name]; !ok || ident.Obj == nil {
+		return false
+	}
+
+	field, ok := ident.Obj.Decl.(*ast.Field)
+
+	return ok && isNullableType(field.Type)
+}
+
+// isNullableType 指针与sql.Null类型可以表示NULL
+func isNullableType(expr ast.Expr) bool {
+	switch inst := expr.(type) {
+	case *ast.StarExpr:
+		return true
+	case *ast.SelectorExpr:
+		return strings.HasPrefix(inst.Sel.Name, "Null")
+	case *ast.IndexExpr:
+		return strings.HasPrefix(types.ExprString(inst), "sql.Null[")
+	}
+
+	return false
+}
+
+func astToSQLPredicateExpression(context *parseContext, callExpr *ast.CallExpr, paramNames map[string]int) (SQLExpression, bool, error) {
+	fun, ok := callExpr.Fun.(*ast.SelectorExpr)
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	var argCount int
+
+	switch fun.Sel.Name {
+	case "In", "NotIn", "Like", "NotLike":
+		argCount = 2
+	case "Between":
+		argCount = 3
+	case "IsNull", "NotNull":
+		argCount = 1
+	default:
+		return nil, false, nil
+	}
+
+	if len(callExpr.Args) != argCount {
+		return nil, true, newArgError(context, callExpr)
+	}
+
+	sqlColExpr, ok := context.getColumnWithExpr(callExpr.Args[0])
+
+	if !ok {
+		return nil, true, newArgError(context, callExpr)
+	}
+
+	var args []SQLExpression
+
+	for _, arg := range callExpr.Args[1:] {
+		if fun.Sel.Name == "In" || fun.Sel.Name == "NotIn" {
+			values, err := astToSQLListExpression(context, arg, paramNames)
+
+			if err != nil {
+				return nil, true, err
+			}
+
+			args = append(args, values)
+			continue
+		}
+
+		sqlExpr, err := astToSQLExpression(arg, context, paramNames)
+
+		if err != nil {
+			return nil, true, err
+		}
+
+		args = append(args, sqlExpr)
+	}
+
+	switch fun.Sel.Name {
+	case "In", "NotIn":
+		return &SQLInExpression{column: sqlColExpr, values: args[0], not: fun.Sel.Name == "NotIn"}, true, nil
+	case "Like", "NotLike":
+		return &SQLLikeExpression{column: sqlColExpr, pattern: args[0], not: fun.Sel.Name == "NotLike"}, true, nil
+	case "Between":
+		return &SQLBetweenExpression{column: sqlColExpr, from: args[0], to: args[1]}, true, nil
+	}
+
+	return &SQLIsNullExpression{column: sqlColExpr, not: fun.Sel.Name == "NotNull"}, true, nil
+}
+
+func astToSQLListExpression(context *parseContext, expr ast.Expr, paramNames map[string]int) (SQLExpression, error) {
+	switch inst := expr.(type) {
+	case *ast.Ident:
+		if _, ok := paramNames[inst.Name]; ok {
+			return &SQLParameterExpression{name: inst.Name, isList: true}, nil
+		}
+	case *ast.CompositeLit:
+		list := &SQLListExpression{}
+
+		for _, elt := range inst.Elts {
+			lit, ok := elt.(*ast.BasicLit)
+
+			if !ok {
+				return nil, newArgError(context, elt)
+			}
+
+			list.items = append(list.items, &SQLLiteralExpression{value: lit.Value})
+		}
+
+		if len(list.items) > 0 {
+			return list, nil
+		}
+	}
+
+	return nil, newArgError(context, expr)
 }
 
 func getTableNameWithExpr(context *parseContext, fromExpr *ast.CallExpr) (string, error) {
@@ -785,6 +1520,118 @@ func getTableNameWithExpr(context *parseContext, fromExpr *ast.CallExpr) (string
 	return "", newArgError(context, fromExpr)
 }
 
+func astToSQLLimitExpression(context *parseContext, funcDecl *ast.FuncDecl, expr ast.Expr) (SQLExpression, error) {
+	switch inst := expr.(type) {
+	case *ast.Ident:
+		if _, ok := getFuncParamNames(funcDecl)[inst.Name]; ok {
+			return &SQLParameterExpression{name: inst.Name}, nil
+		}
+	case *ast.BasicLit:
+		if inst.Kind == token.INT {
+			return &SQLLiteralExpression{value: inst.Value}, nil
+		}
+	}
+
+	return nil, newArgError(context, expr)
+}
+
+func astToSQLSelectExpression(context *parseContext, expr ast.Expr) (SQLExpression, error) {
+	if callExpr, ok := expr.(*ast.CallExpr); ok {
+		return astToSQLAggregateExpression(context, callExpr)
+	}
+
+	sqlColExpr, ok := context.getColumnWithExpr(expr)
+
+	if !ok {
+		return nil, newArgError(context, expr)
+	}
+
+	return sqlColExpr, nil
+}
+
+func astToSQLAggregateExpression(context *parseContext, callExpr *ast.CallExpr) (*SQLAggregateExpression, error) {
+	fun, ok := callExpr.Fun.(*ast.SelectorExpr)
+
+	if !ok {
+		return nil, newArgError(context, callExpr)
+	}
+
+	sqlAggExpr := &SQLAggregateExpression{}
+
+	switch fun.Sel.Name {
+	case "Count":
+		if len(callExpr.Args) > 1 {
+			return nil, newArgError(context, callExpr)
+		}
+	case "Sum", "Avg", "Min", "Max":
+		if len(callExpr.Args) != 1 {
+			return nil, newArgError(context, callExpr)
+		}
+	default:
+		return nil, newUnsupportedError(context, callExpr)
+	}
+
+	sqlAggExpr.function = fun.Sel.Name
+
+	if len(callExpr.Args) == 1 {
+		sqlColExpr, ok := context.getColumnWithExpr(callExpr.Args[0])
+
+		if !ok {
+			return nil, newArgError(context, callExpr)
+		}
+
+		sqlAggExpr.column = sqlColExpr
+	}
+
+	return sqlAggExpr, nil
+}
+
+func astToSQLJoinExpression(context *parseContext, funcDecl *ast.FuncDecl, joinExpr *ast.CallExpr) (*SQLJoinExpression, error) {
+	if len(joinExpr.Args) != 2 {
+		return nil, newArgError(context, joinExpr)
+	}
+
+	entityName, ok := joinExpr.Args[0].(*ast.Ident)
+
+	if !ok {
+		return nil, newArgError(context, joinExpr)
+	}
+
+	entity, ok := context.entity[entityName.Name]
+
+	if !ok {
+		return nil, newArgError(context, joinExpr)
+	}
+
+	condition, err := astToSQLExpression(joinExpr.Args[1], context, getFuncParamNames(funcDecl))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if isDynamicExpression(condition) {
+		return nil, newArgError(context, joinExpr)
+	}
+
+	sqlJoinExpr := &SQLJoinExpression{}
+	sqlJoinExpr.table = entity.tableName
+	sqlJoinExpr.alias = entityName.Name
+	sqlJoinExpr.condition = condition
+
+	switch joinExpr.Fun.(*ast.SelectorExpr).Sel.Name {
+	case "InnerJoin":
+		sqlJoinExpr.joinType = "INNER JOIN"
+	case "LeftJoin":
+		sqlJoinExpr.joinType = "LEFT JOIN"
+	case "RightJoin":
+		sqlJoinExpr.joinType = "RIGHT JOIN"
+	case "FullJoin":
+		sqlJoinExpr.joinType = "FULL JOIN"
+	}
+
+	return sqlJoinExpr, nil
+}
+
 func astToSQLWhereExpression(context *parseContext, funcDecl *ast.FuncDecl, whereExpr *ast.CallExpr) (SQLExpression, error) {
 	if whereExpr == nil {
 		return nil, nil
@@ -798,13 +1645,38 @@ func astToSQLWhereExpression(context *parseContext, funcDecl *ast.FuncDecl, wher
 	sqlWhereExpr, err := astToSQLExpression(whereExpr.Args[0], context, funcParamNames)
 
 	if err != nil {
-		return nil, newArgError(context, whereExpr)
+		return nil, err
+	}
+
+	sqlWhereExpr, ok := toDynamicWhereExpression(sqlWhereExpr)
+
+	if !ok {
+		return nil, newUnsupportedError(context, whereExpr)
 	}
 
 	return sqlWhereExpr, nil
 }
 
 func genDeleteFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
+	query, err := buildDeleteQuery(context, funcDecl, "query")
+
+	if err != nil {
+		return err
+	}
+
+	funcResultList := []*ast.Field{newASTField(newASTRefExpr("sql.Result"), "")}
+
+	genMethodBegin(context, funcDecl.Name.Name, funcDecl.Type.Params.List, funcResultList, funcDecl.Doc)
+
+	genQueryDeclaration(context, query)
+	genExec(context, query)
+
+	genMethodEnd(context)
+
+	return nil
+}
+
+func buildDeleteQuery(context *parseContext, funcDecl *ast.FuncDecl, name string) (*sqlQuery, error) {
 	var whereExpr *ast.CallExpr
 	var deleteExpr *ast.CallExpr
 
@@ -824,7 +1696,7 @@ func genDeleteFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
 	tableName, err := getTableNameWithExpr(context, deleteExpr)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	deleteStmt.table = tableName
@@ -832,38 +1704,35 @@ func genDeleteFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
 	sqlWhereExpr, err := astToSQLWhereExpression(context, funcDecl, whereExpr)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	deleteStmt.where = sqlWhereExpr
 
-	funcResultList := []*ast.Field{newASTField(newASTRefExpr("sql.Result"), "")}
-
-	genMethodBegin(context, funcDecl.Name.Name, funcDecl.Type.Params.List, funcResultList, funcDecl.Doc)
+	return buildSQLQuery(context, funcDecl, name, deleteStmt.where, getDeleteStmtSqlParamList(deleteStmt),
+		func(builder SQLBuilder) { builder.WriteDeleteStatement(deleteStmt) })
+}
 
-	context.sqlBuilder.Reset()
-	context.sqlBuilder.WriteDeleteStatement(deleteStmt)
-	sqlText := context.sqlBuilder.String()
+func genUpdateFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
+	query, err := buildUpdateQuery(context, funcDecl, "query")
 
-	generator := context.generator
-	generator.writeConstDeclaration("query", sqlText)
-	generator.write("return db.ExecContext(context.Background(), query")
+	if err != nil {
+		return err
+	}
 
-	sqlParamList := getDeleteStmtSqlParamList(deleteStmt)
+	funcResultList := []*ast.Field{newASTField(newASTRefExpr("sql.Result"), "")}
 
-	for _, p := range sqlParamList {
-		generator.write(", ")
-		generator.write(p.name)
-	}
+	genMethodBegin(context, funcDecl.Name.Name, funcDecl.Type.Params.List, funcResultList, funcDecl.Doc)
 
-	generator.writeLine(")")
+	genQueryDeclaration(context, query)
+	genExec(context, query)
 
 	genMethodEnd(context)
 
 	return nil
 }
 
-func genUpdateFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
+func buildUpdateQuery(context *parseContext, funcDecl *ast.FuncDecl, name string) (*sqlQuery, error) {
 	var updateExprList []*ast.CallExpr
 	var whereExpr *ast.CallExpr
 	var fromExpr *ast.CallExpr
@@ -887,7 +1756,7 @@ func genUpdateFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
 		tableName, err := getTableNameWithExpr(context, fromExpr)
 
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		updateStmt.table = tableName
@@ -896,12 +1765,12 @@ func genUpdateFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
 	if sqlWhereExpr, err := astToSQLWhereExpression(context, funcDecl, whereExpr); err == nil {
 		updateStmt.where = sqlWhereExpr
 	} else {
-		return err
+		return nil, err
 	}
 
 	for _, updateExpr := range updateExprList {
 		if len(updateExpr.Args) != 2 {
-			return newArgError(context, updateExpr)
+			return nil, newArgError(context, updateExpr)
 		}
 
 		funcFuncNames := getFuncParamNames(funcDecl)
@@ -909,11 +1778,11 @@ func genUpdateFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
 		sqlExpr, err := astToSQLExpression(updateExpr.Args[0], context, funcFuncNames)
 
 		if err != nil {
-			return newArgError(context, updateExpr)
+			return nil, err
 		}
 
 		if _, ok := sqlExpr.(*SQLColumnExpression); !ok {
-			return newArgError(context, updateExpr)
+			return nil, newArgError(context, updateExpr)
 		}
 
 		sqlAssignExpr := &SQLBinaryExpression{}
@@ -922,7 +1791,7 @@ func genUpdateFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
 		sqlExpr, err = astToSQLExpression(updateExpr.Args[1], context, funcFuncNames)
 
 		if err != nil {
-			return newArgError(context, updateExpr)
+			return nil, err
 		}
 
 		if _, ok := sqlExpr.(*SQLParameterExpression); ok {
@@ -930,7 +1799,7 @@ func genUpdateFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
 		} else if _, ok := sqlExpr.(*SQLLiteralExpression); ok {
 
 		} else {
-			return newArgError(context, updateExpr)
+			return nil, newArgError(context, updateExpr)
 		}
 
 		sqlAssignExpr.right = sqlExpr
@@ -938,88 +1807,240 @@ func genUpdateFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
 		updateStmt.updateList = append(updateStmt.updateList, sqlAssignExpr)
 	}
 
-	funcResultList := []*ast.Field{newASTField(newASTRefExpr("sql.Result"), "")}
+	return buildSQLQuery(context, funcDecl, name, updateStmt.where, getUpdateStmtSqlParamList(updateStmt),
+		func(builder SQLBuilder) { builder.WriteUpdateStatement(updateStmt) })
+}
 
-	genMethodBegin(context, funcDecl.Name.Name, funcDecl.Type.Params.List, funcResultList, funcDecl.Doc)
+func genInsertFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
+	insertCall, objName := getInsertCall(funcDecl)
+	insertQuery, err := buildInsertQuery(context, funcDecl, insertCall, "query", objName)
 
-	context.sqlBuilder.Reset()
-	context.sqlBuilder.WriteUpdateStatement(updateStmt)
-	sqlText := context.sqlBuilder.String()
+	if err != nil {
+		return err
+	}
 
 	generator := context.generator
-	generator.writeConstDeclaration("query", sqlText)
-	generator.write("return db.ExecContext(context.Background(), query")
 
-	sqlParamList := getUpdateStmtSqlParamList(updateStmt)
+	if objName == "" {
+		funcResultList := []*ast.Field{newASTField(newASTRefExpr("sql.Result"), "")}
 
-	for _, p := range sqlParamList {
-		generator.write(", ")
-		generator.write(p.name)
-	}
+		genMethodBegin(context, funcDecl.Name.Name, funcDecl.Type.Params.List, funcResultList, funcDecl.Doc)
+	} else {
+		paramList := getContextParamList(context)
+		paramList = append(paramList, newASTField(newASTRefExpr("sqlutil.DbObject"), "db"))
+		paramList = append(paramList, newASTField(newASTRefExpr("*"+insertQuery.entity.name), objName))
+
+		var returnList []*ast.Field
+		returnList = append(returnList, newASTField(newASTRefExpr("sql.Result"), ""))
+		returnList = append(returnList, newASTField(newASTRefExpr("error"), ""))
 
-	generator.writeLine(")")
+		generator.writeDoc(funcDecl.Doc)
+		generator.beginFunc(funcDecl.Name.Name, paramList, returnList)
+	}
 
+	genQueryDeclaration(context, insertQuery.query)
+	generator.writeLine("return ", getInsertExecCode(context, insertQuery, "db"))
 	genMethodEnd(context)
 
 	return nil
 }
 
-func genInsertFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
-	insertModelCall := findSpecCall(funcDecl, "InsertAll")
+// getInsertCall 返回Insert函数中的InsertAll或Insert调用。Insert(columns...)在函数有参数时插入同名的参数，
+// 此时objName为空；否则与InsertAll一样插入生成函数的参数objName的字段
+func getInsertCall(funcDecl *ast.FuncDecl) (insertCall *ast.CallExpr, objName string) {
+	insertCall = findSpecCall(funcDecl, "InsertAll")
 
-	if insertModelCall == nil {
+	if insertCall == nil {
+		insertCall = findSpecCall(funcDecl, "Insert")
+	}
 
-	} else {
-		arg, ok := insertModelCall.Args[0].(*ast.Ident)
+	if insertCall == nil || insertCall.Fun.(*ast.SelectorExpr).Sel.Name == "Insert" && len(funcDecl.Type.Params.List) > 0 {
+		return insertCall, ""
+	}
 
-		if ok {
-			entity, ok := context.entity[arg.Name]
+	return insertCall, "o"
+}
 
-			if !ok {
-				return newArgError(context, insertModelCall)
-			}
+// insertQuery INSERT语句，identityDest不为空时插入后将自增列写入identityDest
+type insertQuery struct {
+	query        *sqlQuery
+	entity       *table
+	identityDest string
+	identityMode identityMode
+}
 
-			generator := context.generator
+type identitySQLBuilder interface {
+	identityMode() identityMode
+}
+
+// buildInsertQuery 返回InsertAll(entity)或Insert(columns...)的查询。objName不为空时插入objName的字段，
+// 方言支持时回写未插入的自增列；否则Insert的每一列插入名称相同（不区分大小写）的函数参数
+func buildInsertQuery(context *parseContext, funcDecl *ast.FuncDecl, insertCall *ast.CallExpr, name string, objName string) (*insertQuery, error) {
+	entity, columns, err := getInsertColumns(context, insertCall)
+
+	if err != nil {
+		return nil, err
+	}
 
-			var paramList []*ast.Field
-			paramList = append(paramList, newASTField(newASTRefExpr("sqlutil.DbObject"), "db"))
-			paramList = append(paramList, newASTField(newASTRefExpr("*"+entity.name), "o"))
+	insertStmt := &SQLInsertStatement{table: entity.tableName}
+	inserted := make(map[*column]bool)
 
-			var returnList []*ast.Field
-			returnList = append(returnList, newASTField(newASTRefExpr("sql.Result"), ""))
-			returnList = append(returnList, newASTField(newASTRefExpr("error"), ""))
+	for i, col := range columns {
+		paramName := objName + "." + col.name
 
-			generator.beginFunc(funcDecl.Name.Name, paramList, returnList)
+		if objName == "" {
+			if paramName, err = getInsertParam(context, funcDecl, insertCall.Args[i], col); err != nil {
+				return nil, err
+			}
+		}
 
-			context.sqlBuilder.Reset()
-			context.sqlBuilder.WriteInsertStatement(
-				tableToInsertStatement(context.sqlBuilder, nil, entity))
+		insertStmt.columns = append(insertStmt.columns, col.columnName)
+		insertStmt.values = append(insertStmt.values, &SQLParameterExpression{name: paramName})
+		inserted[col] = true
+	}
 
-			sqlText := context.sqlBuilder.String()
+	result := &insertQuery{entity: entity}
 
-			generator.writeConstDeclaration("query", sqlText)
+	if builder, ok := context.sqlBuilder.(identitySQLBuilder); ok && objName != "" {
+		for _, col := range entity.columns {
+			if !col.isIdentity || inserted[col] {
+				continue
+			}
 
-			generator.write("return db.ExecContext(context.Background(), query")
+			if mode := builder.identityMode(); mode != identityNone {
+				result.identityDest = "&" + objName + "." + col.name
+				result.identityMode = mode
 
-			for _, col := range entity.columns {
-				if col.isIdentity {
-					continue
+				if mode != identityLastInsertID {
+					insertStmt.returning = col.columnName
 				}
+			}
+
+			break
+		}
+	}
+
+	result.query, err = buildSQLQuery(context, insertCall, name, nil, getInsertStmtSqlParamList(insertStmt),
+		func(builder SQLBuilder) { builder.WriteInsertStatement(insertStmt) })
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// getInsertExecCode 返回执行INSERT语句的代码，需要回写自增列时使用ExecReturning或ExecLastInsertId
+func getInsertExecCode(context *parseContext, q *insertQuery, dbName string) string {
+	switch q.identityMode {
+	case identityLastInsertID:
+		return "sqlutil.ExecLastInsertId(" + getContextArg(context) + ", " + dbName + ", " + getQueryTextCode(q.query) + ", " +
+			q.identityDest + getQueryArgsCode(q.query) + ")"
+	case identityReturning, identityOutput:
+		return "sqlutil.ExecReturning(" + getContextArg(context) + ", " + dbName + ", " + getQueryTextCode(q.query) + ", " +
+			q.identityDest + getQueryArgsCode(q.query) + ")"
+	}
+
+	return getExecCode(context, q.query, dbName)
+}
+
+// getInsertColumns 返回InsertAll(entity)中所有的非自增列，或Insert(columns...)中的列
+func getInsertColumns(context *parseContext, insertCall *ast.CallExpr) (*table, []*column, error) {
+	if insertCall.Fun.(*ast.SelectorExpr).Sel.Name == "InsertAll" {
+		entity, err := getInsertEntity(context, insertCall)
+
+		if err != nil {
+			return nil, nil, err
+		}
 
-				generator.write(",")
-				generator.write("o.")
-				generator.write(col.name)
+		var columns []*column
+
+		for _, col := range entity.columns {
+			if !col.isIdentity {
+				columns = append(columns, col)
 			}
+		}
 
-			generator.write(")")
-			generator.writeLine()
-			generator.endBlock()
-		} else {
-			return newArgError(context, insertModelCall)
+		return entity, columns, nil
+	}
+
+	if len(insertCall.Args) == 0 {
+		return nil, nil, newArgError(context, insertCall)
+	}
+
+	var entity *table
+	var columns []*column
+
+	for _, arg := range insertCall.Args {
+		colExpr, ok := context.getColumnWithExpr(arg)
+
+		if !ok {
+			return nil, nil, context.newError(arg, "%s is not a column of an entity", types.ExprString(arg))
+		}
+
+		col := colExpr.source
+
+		if entity != nil && col.table != entity {
+			return nil, nil, context.newError(arg, "%s is not a column of %s, Insert columns must belong to the same entity",
+				types.ExprString(arg), entity.name)
+		}
+
+		for _, c := range columns {
+			if c == col {
+				return nil, nil, context.newError(arg, "column %s is inserted more than once", col.name)
+			}
 		}
+
+		entity = col.table
+		columns = append(columns, col)
 	}
 
-	return nil
+	return entity, columns, nil
+}
+
+// getInsertParam 返回与列名称相同（不区分大小写）的函数参数，参数的类型需要与列一致
+func getInsertParam(context *parseContext, funcDecl *ast.FuncDecl, columnExpr ast.Expr, col *column) (string, error) {
+	for _, field := range funcDecl.Type.Params.List {
+		for _, ident := range field.Names {
+			if !strings.EqualFold(ident.Name, col.name) {
+				continue
+			}
+
+			obj := context.typeInfo.Defs[ident]
+			tv, ok := context.typeInfo.Types[columnExpr]
+
+			if obj != nil && ok && !isCompatibleType(tv.Type, obj.Type()) {
+				qualifier := func(p *types.Package) string { return p.Name() }
+
+				return "", context.newError(columnExpr, "cannot insert %s parameter %s into %s column %s",
+					types.TypeString(obj.Type(), qualifier), ident.Name, types.TypeString(tv.Type, qualifier), col.name)
+			}
+
+			return ident.Name, nil
+		}
+	}
+
+	return "", context.newError(columnExpr, "no parameter named %s for column %s", lowerFirstName(col.name), col.name)
+}
+
+func getInsertEntity(context *parseContext, insertModelCall *ast.CallExpr) (*table, error) {
+	if len(insertModelCall.Args) != 1 {
+		return nil, newArgError(context, insertModelCall)
+	}
+
+	arg, ok := insertModelCall.Args[0].(*ast.Ident)
+
+	if !ok {
+		return nil, newArgError(context, insertModelCall)
+	}
+
+	entity, ok := context.entity[arg.Name]
+
+	if !ok {
+		return nil, newArgError(context, insertModelCall)
+	}
+
+	return entity, nil
 }
 
 func getTypeName(expr ast.Expr) string {
@@ -1054,22 +2075,44 @@ Loop:
 func getTags(code string) map[string]string {
 	result := make(map[string]string)
 
-	tags := strings.Fields(code)
+	reg := regexp.MustCompile(`(\w+):"((?:[^"\\]|\\.)*)"`)
 
-	reg := regexp.MustCompile(`(\w+):"(.+)"`)
-	for _, t := range tags {
-		kv := reg.FindStringSubmatch(t)
+	for _, kv := range reg.FindAllStringSubmatch(code, -1) {
+		value, err := strconv.Unquote(`"` + kv[2] + `"`)
 
-		if len(kv) != 3 {
+		if err != nil {
 			continue
 		}
 
-		result[kv[1]] = kv[2]
+		result[kv[1]] = value
 	}
 
 	return result
 }
 
+func setColumnTags(column *column, tag string) error {
+	tags := getTags(tag)
+	colIdent, ok := tags["identity"]
+
+	if ok && colIdent == "true" {
+		column.isIdentity = true
+	}
+
+	colName, ok := tags["name"]
+
+	if ok {
+		column.columnName = colName
+	}
+
+	if tags["null"] == "true" {
+		column.isNull = true
+	}
+
+	column.tag = tag
+
+	return setColumnDDLTags(column, tags)
+}
+
 func addTable(context *parseContext, genDecl *ast.GenDecl) error {
 	table := &table{}
 
@@ -1100,6 +2143,7 @@ func addTable(context *parseContext, genDecl *ast.GenDecl) error {
 			}
 
 			column := &column{}
+			column.table = table
 			column.name = field.Names[0].Name
 
 			switch columnType := field.Type.(type) {
@@ -1107,24 +2151,16 @@ func addTable(context *parseContext, genDecl *ast.GenDecl) error {
 				column.sysType = columnType.Name
 			case *ast.SelectorExpr:
 				column.sysType = getTypeName(columnType)
-				column.isNull = strings.Index(columnType.Sel.Name, "Null") == 0
+			default:
+				column.sysType = types.ExprString(columnType)
 			}
 
-			if field.Tag != nil {
-				tags := getTags(field.Tag.Value)
-				colIdent, ok := tags["identity"]
-
-				if ok && colIdent == "true" {
-					column.isIdentity = true
-				}
-
-				colName, ok := tags["name"]
+			column.isNull = isNullableType(field.Type)
 
-				if ok {
-					column.columnName = colName
+			if field.Tag != nil {
+				if err := setColumnTags(column, field.Tag.Value); err != nil {
+					return newTypeDefError(context, typeSpec.Name.Name, field)
 				}
-
-				column.tag = field.Tag.Value
 			}
 
 			if column.columnName == "" {
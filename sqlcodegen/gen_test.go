@@ -0,0 +1,266 @@
+package sqlcodegen
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// generateTestPackage 生成testdata中的描述文件，返回所有生成文件的内容
+func generateTestPackage(t *testing.T, name string, opts Options) string {
+	t.Helper()
+
+	files, err := GeneratePackage(filepath.Join("testdata", name), opts)
+
+	if err != nil {
+		t.Fatalf("generate %s: %v", name, err)
+	}
+
+	var code strings.Builder
+
+	for _, f := range files {
+		code.Write(f.Content)
+	}
+
+	return code.String()
+}
+
+func TestOuterJoinScanNullable(t *testing.T) {
+	code := generateTestPackage(t, "join", Options{Dialect: DialectPostgres})
+
+	tests := []struct {
+		name string
+		scan string
+	}{
+		{"LEFT JOIN", "rows.Scan(&o.UserName, sqlutil.ScanNullable(&o.OrderID), sqlutil.ScanNullable(&o.Amount))"},
+		{"RIGHT JOIN", "rows.Scan(sqlutil.ScanNullable(&o.UserName), &o.OrderID)"},
+		{"FULL JOIN", "rows.Scan(sqlutil.ScanNullable(&o.UserName), sqlutil.ScanNullable(&o.Amount), sqlutil.ScanNullable(&o.Name))"},
+		{"INNER JOIN", "rows.Scan(&o.UserName, &o.OrderID)"},
+	}
+
+	for _, test := range tests {
+		if !strings.Contains(code, test.scan) {
+			t.Errorf("%s: generated code does not contain %s\n%s", test.name, test.scan, code)
+		}
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	code := generateTestPackage(t, "aggregate", Options{})
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"CountUsers", []string{
+			"const query = " + strconv.Quote("SELECT COUNT(*)\nFROM User\nWHERE Sex = ?\n"),
+			"func CountUsers(ctx context.Context, db sqlutil.DbObject, sex byte) (int64, error)",
+		}},
+		{"GetOrderStats", []string{
+			"const query = " + strconv.Quote("SELECT user.UserID, COUNT(*), SUM(order.Amount)\nFROM User user\n"+
+				"INNER JOIN Order order ON order.UserID = user.UserID\nGROUP BY user.UserID\nHAVING COUNT(*) > ?\n"),
+			"type GetOrderStatsResult struct {",
+			"\tCount     int64",
+			"\tSumAmount sql.NullFloat64",
+		}},
+		{"GetItemStats", []string{
+			"const query = " + strconv.Quote("SELECT UserID, COUNT(OrderID), MIN(Items), MAX(Amount), AVG(Items)\nFROM Order\n"+
+				"GROUP BY UserID\nHAVING SUM(Amount) >= 100 AND MAX(Items) < 10\nORDER BY UserID DESC\n"),
+			"type GetItemStatsResult struct {",
+			"\tCountOrderID int64",
+			"\tMinItems     sql.NullInt64",
+			"\tMaxAmount    sql.NullFloat64",
+			"\tAvgItems     sql.NullFloat64",
+		}},
+	}
+
+	for _, test := range tests {
+		for _, want := range test.want {
+			if !strings.Contains(code, want) {
+				t.Errorf("%s: generated code does not contain %s\n%s", test.name, want, code)
+			}
+		}
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	code := generateTestPackage(t, "predicate", Options{Dialect: DialectPostgres})
+
+	for _, want := range []string{
+		// 切片参数在运行时展开
+		`query.Where("\"UserID\" IN (?)", sqlutil.List(ids))`,
+		`query.Where("\"UserName\" LIKE ?", pattern)`,
+		`query.Where("\"UserName\" NOT IN (?) OR \"UserName\" NOT LIKE ?", sqlutil.List(names), pattern)`,
+		// 常量列表与BETWEEN直接写入SQL语句
+		"const query = " + strconv.Quote("SELECT \"UserID\"\nFROM \"User\"\nWHERE \"Sex\" IN (1, 2) AND \"UserID\" BETWEEN $1 AND $2\n"),
+		"const query = " + strconv.Quote("SELECT \"UserID\"\nFROM \"User\"\nWHERE \"Nick\" IS NULL OR \"UserName\" IS NOT NULL\n"),
+		"const query = " + strconv.Quote("UPDATE \"User\"\nSET \"Nick\" = NULL\nWHERE \"UserID\" = $1\n"),
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code does not contain %s\n%s", want, code)
+		}
+	}
+}
+
+func TestContextParam(t *testing.T) {
+	for _, noContext := range []bool{false, true} {
+		code := generateTestPackage(t, "dialect", Options{NoContext: noContext})
+		file, err := parser.ParseFile(token.NewFileSet(), "dialect.go", code, 0)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wantParams := "ctx context.Context, db sqlutil.DbObject"
+
+		if noContext {
+			wantParams = "db sqlutil.DbObject"
+		}
+
+		var funcs int
+
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+
+			if !ok {
+				continue
+			}
+
+			funcs++
+
+			var params []string
+
+			for _, field := range funcDecl.Type.Params.List[:len(strings.Split(wantParams, ","))] {
+				params = append(params, field.Names[0].Name+" "+types.ExprString(field.Type))
+			}
+
+			if got := strings.Join(params, ", "); got != wantParams {
+				t.Errorf("nocontext %v: %s starts with parameters %s, want %s", noContext, funcDecl.Name.Name, got, wantParams)
+			}
+		}
+
+		if funcs == 0 {
+			t.Fatalf("no functions generated\n%s", code)
+		}
+
+		// 不带context参数时使用context.Background()，生成的代码中不再出现ctx
+		if noContext && (strings.Contains(code, "ctx") || !strings.Contains(code, "context.Background()")) {
+			t.Errorf("nocontext: generated code uses ctx\n%s", code)
+		}
+
+		if !noContext && strings.Contains(code, "context.Background()") {
+			t.Errorf("generated code does not pass ctx through\n%s", code)
+		}
+	}
+}
+
+func TestScanErrorsReturned(t *testing.T) {
+	code := generateTestPackage(t, "returns", Options{})
+	file, err := parser.ParseFile(token.NewFileSet(), "returns.go", code, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var scans int
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		stmt, ok := n.(*ast.ExprStmt)
+
+		if !ok {
+			if call, ok := n.(*ast.CallExpr); ok && types.ExprString(call.Fun) == "rows.Scan" {
+				scans++
+			}
+
+			return true
+		}
+
+		// rows.Scan 与 rows.Err 的返回值不能被丢弃
+		if call, ok := stmt.X.(*ast.CallExpr); ok {
+			switch name := types.ExprString(call.Fun); name {
+			case "rows.Scan", "rows.Err":
+				t.Errorf("error of %s is discarded", name)
+			}
+		}
+
+		return true
+	})
+
+	if scans != 6 {
+		t.Errorf("got %d rows.Scan calls, want 6\n%s", scans, code)
+	}
+
+	// 遍历rows的代码必须检查rows.Err()
+	if strings.Contains(code, "rows.Next()") && !strings.Contains(code, "rows.Err()") {
+		t.Errorf("generated code iterates rows without checking rows.Err()\n%s", code)
+	}
+}
+
+func TestGroupedParams(t *testing.T) {
+	code := generateTestPackage(t, "params", Options{Dialect: DialectPostgres})
+
+	for _, want := range []string{
+		"func GetUsersBetween(ctx context.Context, db sqlutil.DbObject, minID, maxID int64, sex byte) ([]*User, error)",
+		"}, minID, maxID, sex)",
+		"func GetUserPage(ctx context.Context, db sqlutil.DbObject, page, size int) (*sqlutil.Page[*User], error)",
+		"result := sqlutil.NewPage[*User](int(page), int(size))",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code does not contain %s\n%s", want, code)
+		}
+	}
+}
+
+func TestGeneratePackageDoesNotLeakGoroutines(t *testing.T) {
+	generateTestPackage(t, "join", Options{})
+
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 20; i++ {
+		generateTestPackage(t, "join", Options{})
+		generateTestPackage(t, "params", Options{})
+	}
+
+	// 给已经结束的goroutine留出退出的时间
+	for i := 0; i < 50 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines before GeneratePackage, %d after", before, after)
+	}
+}
+
+func TestInsertWithoutMatchingParam(t *testing.T) {
+	src := setTestGOPATH(t)
+	descDir := filepath.Join(src, "desc")
+
+	writeTestFile(t, filepath.Join(descDir, "desc.go"), `package desc
+
+import "github.com/YiCodes/gosql/sqlcodegen"
+
+type User struct {
+	UserID   int64
+	UserName string
+}
+
+var user User
+
+func InsertUserName(userID int64, name string) {
+	sqlcodegen.Insert(user.UserID, user.UserName)
+}
+`)
+
+	_, err := GeneratePackage(descDir, Options{})
+
+	if err == nil || !strings.Contains(err.Error(), "desc.go:13:33: error: no parameter named userName for column UserName") {
+		t.Errorf("GeneratePackage = %v, want an error about parameter userName", err)
+	}
+}
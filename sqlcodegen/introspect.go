@@ -0,0 +1,483 @@
+package sqlcodegen
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type schemaReader interface {
+	readSchema(ctx context.Context, db *sql.DB) (*Schema, error)
+}
+
+// ReadSchema 从数据库读取当前的数据库结构，列类型使用与gosql ddl相同的写法
+func ReadSchema(ctx context.Context, db *sql.DB, dialect Dialect) (*Schema, error) {
+	d, err := getSQLDialect(dialect)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reader, ok := d.(schemaReader)
+
+	if !ok {
+		return nil, fmt.Errorf("error: dialect %q does not support reading schema", string(dialect))
+	}
+
+	return reader.readSchema(ctx, db)
+}
+
+type schemaBuilder struct {
+	schema *Schema
+}
+
+func (b *schemaBuilder) getTable(name string) *TableSchema {
+	t, ok := b.schema.GetTable(name)
+
+	if !ok {
+		t = &TableSchema{Name: name}
+		b.schema.Tables = append(b.schema.Tables, t)
+	}
+
+	return t
+}
+
+func (b *schemaBuilder) readColumns(ctx context.Context, db *sql.DB, query string, scan func(rows *sql.Rows) (string, *ColumnSchema, error)) error {
+	rows, err := db.QueryContext(ctx, query)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		tableName, col, err := scan(rows)
+
+		if err != nil {
+			return err
+		}
+
+		t := b.getTable(tableName)
+		t.Columns = append(t.Columns, col)
+	}
+
+	return rows.Err()
+}
+
+func (b *schemaBuilder) readPrimaryKeys(ctx context.Context, db *sql.DB, query string) error {
+	rows, err := db.QueryContext(ctx, query)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var tableName, columnName string
+
+		if err := rows.Scan(&tableName, &columnName); err != nil {
+			return err
+		}
+
+		t := b.getTable(tableName)
+		t.PrimaryKey = append(t.PrimaryKey, columnName)
+	}
+
+	return rows.Err()
+}
+
+func (b *schemaBuilder) readIndexes(ctx context.Context, db *sql.DB, query string) error {
+	rows, err := db.QueryContext(ctx, query)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var tableName, indexName, columnName string
+		var unique, isConstraint bool
+
+		if err := rows.Scan(&tableName, &indexName, &unique, &isConstraint, &columnName); err != nil {
+			return err
+		}
+
+		t := b.getTable(tableName)
+		index, ok := t.GetIndex(indexName)
+
+		if !ok {
+			index = &IndexSchema{Name: indexName, Unique: unique, isConstraint: isConstraint}
+			t.Indexes = append(t.Indexes, index)
+		}
+
+		index.Columns = append(index.Columns, columnName)
+	}
+
+	return rows.Err()
+}
+
+const informationSchemaPrimaryKeyQuery = `SELECT tc.TABLE_NAME, kcu.COLUMN_NAME
+FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc
+INNER JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu
+ON kcu.CONSTRAINT_NAME = tc.CONSTRAINT_NAME AND kcu.TABLE_SCHEMA = tc.TABLE_SCHEMA AND kcu.TABLE_NAME = tc.TABLE_NAME
+WHERE tc.CONSTRAINT_TYPE = 'PRIMARY KEY' AND tc.TABLE_SCHEMA = %s
+ORDER BY tc.TABLE_NAME, kcu.ORDINAL_POSITION`
+
+func (postgresDialect) readSchema(ctx context.Context, db *sql.DB) (*Schema, error) {
+	b := &schemaBuilder{schema: &Schema{}}
+
+	err := b.readColumns(ctx, db, `SELECT c.table_name, c.column_name, c.data_type, c.character_maximum_length,
+c.numeric_precision, c.numeric_scale, c.is_nullable, c.column_default, c.is_identity
+FROM information_schema.columns c
+INNER JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+WHERE c.table_schema = current_schema() AND t.table_type = 'BASE TABLE'
+ORDER BY c.table_name, c.ordinal_position`, func(rows *sql.Rows) (string, *ColumnSchema, error) {
+		var tableName, dataType, nullable, identity string
+		var size, precision, scale sql.NullInt64
+		var defaultValue sql.NullString
+		col := &ColumnSchema{}
+
+		err := rows.Scan(&tableName, &col.Name, &dataType, &size, &precision, &scale, &nullable, &defaultValue, &identity)
+
+		col.Type = getPostgresColumnType(dataType, size, precision, scale)
+		col.Nullable = nullable == "YES"
+		col.Identity = identity == "YES" || strings.HasPrefix(defaultValue.String, "nextval(")
+
+		if !col.Identity {
+			col.Default = trimDefaultCast(defaultValue.String)
+		}
+
+		return tableName, col, err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.readPrimaryKeys(ctx, db, fmt.Sprintf(informationSchemaPrimaryKeyQuery, "current_schema()")); err != nil {
+		return nil, err
+	}
+
+	err = b.readIndexes(ctx, db, `SELECT t.relname, i.relname, ix.indisunique, con.oid IS NOT NULL, a.attname
+FROM pg_index ix
+INNER JOIN pg_class t ON t.oid = ix.indrelid
+INNER JOIN pg_class i ON i.oid = ix.indexrelid
+INNER JOIN pg_namespace n ON n.oid = t.relnamespace
+INNER JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+INNER JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+LEFT JOIN pg_constraint con ON con.conindid = ix.indexrelid AND con.contype = 'u'
+WHERE n.nspname = current_schema() AND NOT ix.indisprimary
+ORDER BY t.relname, i.relname, k.ord`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return b.schema, nil
+}
+
+func getPostgresColumnType(dataType string, size sql.NullInt64, precision sql.NullInt64, scale sql.NullInt64) string {
+	switch dataType {
+	case "character varying":
+		return sizedType("VARCHAR", int(size.Int64), "")
+	case "character":
+		return sizedType("CHAR", int(size.Int64), "")
+	case "numeric":
+		if precision.Valid {
+			return "NUMERIC(" + strconv.FormatInt(precision.Int64, 10) + ", " + strconv.FormatInt(scale.Int64, 10) + ")"
+		}
+
+		return "NUMERIC"
+	case "timestamp without time zone":
+		return "TIMESTAMP"
+	case "timestamp with time zone":
+		return "TIMESTAMPTZ"
+	}
+
+	return strings.ToUpper(dataType)
+}
+
+var defaultCastRegexp = regexp.MustCompile(`^(.*?)::[a-zA-Z ]+(\[\])?$`)
+
+func trimDefaultCast(value string) string {
+	if m := defaultCastRegexp.FindStringSubmatch(value); m != nil {
+		return m[1]
+	}
+
+	return value
+}
+
+func (mysqlDialect) readSchema(ctx context.Context, db *sql.DB) (*Schema, error) {
+	b := &schemaBuilder{schema: &Schema{}}
+
+	err := b.readColumns(ctx, db, `SELECT c.TABLE_NAME, c.COLUMN_NAME, c.DATA_TYPE, c.COLUMN_TYPE, c.IS_NULLABLE, c.COLUMN_DEFAULT, c.EXTRA
+FROM INFORMATION_SCHEMA.COLUMNS c
+INNER JOIN INFORMATION_SCHEMA.TABLES t ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME
+WHERE c.TABLE_SCHEMA = DATABASE() AND t.TABLE_TYPE = 'BASE TABLE'
+ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION`, func(rows *sql.Rows) (string, *ColumnSchema, error) {
+		var tableName, dataType, columnType, nullable, extra string
+		var defaultValue sql.NullString
+		col := &ColumnSchema{}
+
+		err := rows.Scan(&tableName, &col.Name, &dataType, &columnType, &nullable, &defaultValue, &extra)
+
+		col.Type = getMySQLColumnType(columnType)
+		col.Nullable = nullable == "YES"
+		col.Identity = strings.Contains(extra, "auto_increment")
+
+		if defaultValue.Valid && !col.Identity {
+			col.Default = getMySQLDefault(dataType, defaultValue.String, extra)
+		}
+
+		return tableName, col, err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.readPrimaryKeys(ctx, db, fmt.Sprintf(informationSchemaPrimaryKeyQuery, "DATABASE()")); err != nil {
+		return nil, err
+	}
+
+	err = b.readIndexes(ctx, db, `SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE = 0, FALSE, COLUMN_NAME
+FROM INFORMATION_SCHEMA.STATISTICS
+WHERE TABLE_SCHEMA = DATABASE() AND INDEX_NAME <> 'PRIMARY'
+ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return b.schema, nil
+}
+
+var mysqlDisplayWidthRegexp = regexp.MustCompile(`^(TINYINT|SMALLINT|MEDIUMINT|INT|BIGINT)\(\d+\)`)
+
+func getMySQLColumnType(columnType string) string {
+	columnType = strings.ToUpper(columnType)
+
+	if columnType == "TINYINT(1)" {
+		return columnType
+	}
+
+	return mysqlDisplayWidthRegexp.ReplaceAllString(columnType, "$1")
+}
+
+func getMySQLDefault(dataType string, value string, extra string) string {
+	if strings.Contains(extra, "DEFAULT_GENERATED") || strings.HasPrefix(value, "'") {
+		return value
+	}
+
+	switch dataType {
+	case "char", "varchar", "text", "tinytext", "mediumtext", "longtext", "enum", "set":
+		return "'" + strings.Replace(value, "'", "''", -1) + "'"
+	}
+
+	return value
+}
+
+func (sqlServerDialect) readSchema(ctx context.Context, db *sql.DB) (*Schema, error) {
+	b := &schemaBuilder{schema: &Schema{}}
+
+	err := b.readColumns(ctx, db, `SELECT t.name, c.name, ty.name, c.max_length, c.precision, c.scale,
+c.is_nullable, c.is_identity, dc.definition, dc.name
+FROM sys.tables t
+INNER JOIN sys.columns c ON c.object_id = t.object_id
+INNER JOIN sys.types ty ON ty.user_type_id = c.user_type_id
+LEFT JOIN sys.default_constraints dc ON dc.object_id = c.default_object_id
+WHERE t.schema_id = SCHEMA_ID()
+ORDER BY t.name, c.column_id`, func(rows *sql.Rows) (string, *ColumnSchema, error) {
+		var tableName, typeName string
+		var maxLength, precision, scale int
+		var defaultValue, defaultName sql.NullString
+		col := &ColumnSchema{}
+
+		err := rows.Scan(&tableName, &col.Name, &typeName, &maxLength, &precision, &scale,
+			&col.Nullable, &col.Identity, &defaultValue, &defaultName)
+
+		col.Type = getSQLServerColumnType(typeName, maxLength, precision, scale)
+		col.Default = trimDefaultParenthesis(defaultValue.String)
+		col.defaultName = defaultName.String
+
+		return tableName, col, err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.readPrimaryKeys(ctx, db, fmt.Sprintf(informationSchemaPrimaryKeyQuery, "SCHEMA_NAME()")); err != nil {
+		return nil, err
+	}
+
+	err = b.readIndexes(ctx, db, `SELECT t.name, i.name, i.is_unique, i.is_unique_constraint, c.name
+FROM sys.indexes i
+INNER JOIN sys.tables t ON t.object_id = i.object_id
+INNER JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+INNER JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+WHERE t.schema_id = SCHEMA_ID() AND i.is_primary_key = 0 AND i.type > 0 AND ic.is_included_column = 0
+ORDER BY t.name, i.name, ic.key_ordinal`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return b.schema, nil
+}
+
+func getSQLServerColumnType(typeName string, maxLength int, precision int, scale int) string {
+	name := strings.ToUpper(typeName)
+
+	switch name {
+	case "NVARCHAR", "NCHAR":
+		if maxLength < 0 {
+			return name + "(MAX)"
+		}
+
+		return sizedType(name, maxLength/2, "")
+	case "VARCHAR", "CHAR", "VARBINARY", "BINARY":
+		if maxLength < 0 {
+			return name + "(MAX)"
+		}
+
+		return sizedType(name, maxLength, "")
+	case "DECIMAL", "NUMERIC":
+		return name + "(" + strconv.Itoa(precision) + ", " + strconv.Itoa(scale) + ")"
+	}
+
+	return name
+}
+
+func trimDefaultParenthesis(value string) string {
+	for strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		value = value[1 : len(value)-1]
+	}
+
+	return value
+}
+
+func (sqliteDialect) readSchema(ctx context.Context, db *sql.DB) (*Schema, error) {
+	b := &schemaBuilder{schema: &Schema{}}
+
+	rows, err := db.QueryContext(ctx, `SELECT name, sql FROM sqlite_master
+WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+ORDER BY name`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tableSQL := make(map[string]string)
+
+	for rows.Next() {
+		var name, sqlText string
+
+		if err := rows.Scan(&name, &sqlText); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		b.getTable(name)
+		tableSQL[name] = sqlText
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, t := range b.schema.Tables {
+		if err := readSQLiteTable(ctx, db, t, strings.Contains(strings.ToUpper(tableSQL[t.Name]), "AUTOINCREMENT")); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.schema, nil
+}
+
+func readSQLiteTable(ctx context.Context, db *sql.DB, t *TableSchema, autoIncrement bool) error {
+	rows, err := db.QueryContext(ctx, "SELECT name, type, \"notnull\", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid", t.Name)
+
+	if err != nil {
+		return err
+	}
+
+	primaryKey := make(map[int]string)
+
+	for rows.Next() {
+		var notNull bool
+		var pk int
+		var defaultValue sql.NullString
+		col := &ColumnSchema{}
+
+		if err := rows.Scan(&col.Name, &col.Type, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+
+		col.Type = strings.ToUpper(col.Type)
+		col.Nullable = !notNull
+		col.Default = defaultValue.String
+
+		if pk > 0 {
+			primaryKey[pk] = col.Name
+		}
+
+		t.Columns = append(t.Columns, col)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := 1; i <= len(primaryKey); i++ {
+		t.PrimaryKey = append(t.PrimaryKey, primaryKey[i])
+	}
+
+	if autoIncrement && len(t.PrimaryKey) == 1 {
+		if col, ok := t.GetColumn(t.PrimaryKey[0]); ok && col.Type == "INTEGER" {
+			col.Identity = true
+		}
+	}
+
+	rows, err = db.QueryContext(ctx, `SELECT il.name, il."unique", il.origin = 'u', ii.name
+FROM pragma_index_list(?) il, pragma_index_info(il.name) ii
+WHERE il.origin <> 'pk'
+ORDER BY il.name, ii.seqno`, t.Name)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var indexName, columnName string
+		var unique, isConstraint bool
+
+		if err := rows.Scan(&indexName, &unique, &isConstraint, &columnName); err != nil {
+			return err
+		}
+
+		index, ok := t.GetIndex(indexName)
+
+		if !ok {
+			index = &IndexSchema{Name: indexName, Unique: unique, isConstraint: isConstraint}
+			t.Indexes = append(t.Indexes, index)
+		}
+
+		index.Columns = append(index.Columns, columnName)
+	}
+
+	return rows.Err()
+}
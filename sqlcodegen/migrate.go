@@ -0,0 +1,379 @@
+package sqlcodegen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+type migrateDialect interface {
+	ddlDialect
+	addColumnKeyword() string
+	alterColumn(tableName string, from *ColumnSchema, to *ColumnSchema) []string
+	dropIndex(tableName string, index *IndexSchema) string
+	addUniqueConstraint(tableName string, index *IndexSchema) string
+}
+
+// DiffSchema 比较数据库当前的结构与模型生成的结构，返回升级与回退的SQL脚本。
+// 只处理模型中定义的表，数据库中存在而模型中没有的表不会删除。
+func DiffSchema(current *Schema, target *Schema, dialect Dialect) (up string, down string, err error) {
+	d, err := getSQLDialect(dialect)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	md, ok := d.(migrateDialect)
+
+	if !ok {
+		return "", "", fmt.Errorf("error: dialect %q does not support migration", string(dialect))
+	}
+
+	var upList, downList []string
+
+	for _, t := range target.Tables {
+		c, ok := current.GetTable(t.Name)
+
+		if !ok {
+			var buffer bytes.Buffer
+
+			writeCreateTable(&buffer, md, t)
+			writeCreateIndexes(&buffer, md, t)
+
+			upList = append(upList, buffer.String())
+			downList = append(downList, "DROP TABLE "+quoteName(md, t.Name)+";\n")
+			continue
+		}
+
+		tableUp, tableDown := diffTable(md, c, t)
+		upList = append(upList, tableUp...)
+		downList = append(downList, tableDown...)
+	}
+
+	for i, j := 0, len(downList)-1; i < j; i, j = i+1, j-1 {
+		downList[i], downList[j] = downList[j], downList[i]
+	}
+
+	return strings.Join(upList, ""), strings.Join(downList, ""), nil
+}
+
+func diffTable(d migrateDialect, current *TableSchema, target *TableSchema) (up []string, down []string) {
+	tableName := quoteName(d, target.Name)
+	currentIndexes := getIndexList(current)
+	targetIndexes := getIndexList(target)
+
+	for _, index := range currentIndexes {
+		if findIndex(targetIndexes, index) == nil {
+			up = append(up, d.dropIndex(target.Name, index))
+
+			// 唯一约束回退时仍然创建为约束，否则下一次比较会认为约束被替换为索引
+			if index.isConstraint {
+				down = append(down, d.addUniqueConstraint(target.Name, index))
+			} else {
+				down = append(down, getCreateIndex(d, target.Name, index))
+			}
+		}
+	}
+
+	for _, col := range current.Columns {
+		if _, ok := target.GetColumn(col.Name); !ok {
+			up = append(up, "ALTER TABLE "+tableName+" DROP COLUMN "+d.quoteIdentifier(col.Name)+";\n")
+			down = append(down, getAddColumn(d, target.Name, col))
+		}
+	}
+
+	for _, col := range target.Columns {
+		currentCol, ok := current.GetColumn(col.Name)
+
+		if !ok {
+			up = append(up, getAddColumn(d, target.Name, col))
+			down = append(down, "ALTER TABLE "+tableName+" DROP COLUMN "+d.quoteIdentifier(col.Name)+";\n")
+			continue
+		}
+
+		if !isSameColumn(currentCol, col) {
+			up = append(up, strings.Join(d.alterColumn(target.Name, currentCol, col), ""))
+			down = append(down, strings.Join(d.alterColumn(target.Name, col, currentCol), ""))
+		}
+	}
+
+	for _, index := range targetIndexes {
+		if findIndex(currentIndexes, index) == nil {
+			up = append(up, getCreateIndex(d, target.Name, index))
+			down = append(down, d.dropIndex(target.Name, index))
+		}
+	}
+
+	if strings.Join(current.PrimaryKey, ",") != strings.Join(target.PrimaryKey, ",") {
+		up = append(up, "-- primary key of "+target.Name+" changed from ("+strings.Join(current.PrimaryKey, ", ")+
+			") to ("+strings.Join(target.PrimaryKey, ", ")+"), please migrate it manually\n")
+	}
+
+	return up, down
+}
+
+// getIndexList 返回表的索引，单列唯一约束也作为唯一索引比较
+func getIndexList(t *TableSchema) []*IndexSchema {
+	list := append([]*IndexSchema{}, t.Indexes...)
+
+	for _, col := range t.Columns {
+		if col.Unique {
+			list = append(list, &IndexSchema{Name: getIndexName("uq", t.Name, col.Name), Columns: []string{col.Name}, Unique: true})
+		}
+	}
+
+	return list
+}
+
+func findIndex(list []*IndexSchema, index *IndexSchema) *IndexSchema {
+	for _, i := range list {
+		if i.Unique == index.Unique && strings.Join(i.Columns, ",") == strings.Join(index.Columns, ",") {
+			return i
+		}
+	}
+
+	return nil
+}
+
+func getCreateIndex(d migrateDialect, tableName string, index *IndexSchema) string {
+	var buffer bytes.Buffer
+
+	writeCreateIndex(&buffer, d, tableName, index)
+
+	return buffer.String()
+}
+
+func getAddColumn(d migrateDialect, tableName string, col *ColumnSchema) string {
+	var buffer bytes.Buffer
+
+	c := *col
+	c.Unique = false
+
+	buffer.WriteString("ALTER TABLE ")
+	buffer.WriteString(quoteName(d, tableName))
+	buffer.WriteString(d.addColumnKeyword())
+	writeColumnDefinition(&buffer, d, &c, false)
+	buffer.WriteString(";\n")
+
+	return buffer.String()
+}
+
+func isSameColumn(a *ColumnSchema, b *ColumnSchema) bool {
+	return isSameColumnType(a.Type, b.Type) && a.Nullable == b.Nullable && isSameDefault(a.Default, b.Default)
+}
+
+var columnTypeAliases = map[string]string{
+	"DECIMAL":           "NUMERIC",
+	"INT":               "INTEGER",
+	"INT2":              "SMALLINT",
+	"INT4":              "INTEGER",
+	"INT8":              "BIGINT",
+	"BOOL":              "BOOLEAN",
+	"FLOAT4":            "REAL",
+	"FLOAT8":            "DOUBLE PRECISION",
+	"CHARACTER VARYING": "VARCHAR",
+}
+
+func normalizeColumnType(t string) string {
+	t = strings.ToUpper(strings.Join(strings.Fields(t), " "))
+	t = strings.Replace(t, ", ", ",", -1)
+	t = strings.Replace(t, " (", "(", -1)
+
+	base, rest := t, ""
+
+	if i := strings.Index(t, "("); i >= 0 {
+		base, rest = t[:i], t[i:]
+	}
+
+	if alias, ok := columnTypeAliases[base]; ok {
+		base = alias
+	}
+
+	return base + rest
+}
+
+func isSameColumnType(a string, b string) bool {
+	return normalizeColumnType(a) == normalizeColumnType(b)
+}
+
+func normalizeDefault(value string) string {
+	return trimDefaultCast(trimDefaultParenthesis(strings.TrimSpace(value)))
+}
+
+func isSameDefault(a string, b string) bool {
+	return strings.EqualFold(normalizeDefault(a), normalizeDefault(b))
+}
+
+func getAlterColumnPrefix(d sqlDialect, tableName string, col *ColumnSchema) string {
+	return "ALTER TABLE " + quoteName(d, tableName) + " ALTER COLUMN " + d.quoteIdentifier(col.Name)
+}
+
+func alterColumnStandard(d sqlDialect, tableName string, from *ColumnSchema, to *ColumnSchema) []string {
+	var list []string
+	prefix := getAlterColumnPrefix(d, tableName, to)
+
+	if !isSameColumnType(from.Type, to.Type) {
+		list = append(list, prefix+" TYPE "+to.Type+";\n")
+	}
+
+	if from.Nullable != to.Nullable {
+		if to.Nullable {
+			list = append(list, prefix+" DROP NOT NULL;\n")
+		} else {
+			list = append(list, prefix+" SET NOT NULL;\n")
+		}
+	}
+
+	if !isSameDefault(from.Default, to.Default) {
+		if to.Default == "" {
+			list = append(list, prefix+" DROP DEFAULT;\n")
+		} else {
+			list = append(list, prefix+" SET DEFAULT "+to.Default+";\n")
+		}
+	}
+
+	return list
+}
+
+func dropIndexStandard(d sqlDialect, tableName string, index *IndexSchema) string {
+	if index.isConstraint {
+		return "ALTER TABLE " + quoteName(d, tableName) + " DROP CONSTRAINT " + d.quoteIdentifier(index.Name) + ";\n"
+	}
+
+	return "DROP INDEX " + d.quoteIdentifier(index.Name) + ";\n"
+}
+
+func addUniqueConstraintStandard(d sqlDialect, tableName string, index *IndexSchema) string {
+	return "ALTER TABLE " + quoteName(d, tableName) + " ADD CONSTRAINT " + d.quoteIdentifier(index.Name) +
+		" UNIQUE (" + quoteNameList(d, index.Columns) + ");\n"
+}
+
+func (defaultDialect) addColumnKeyword() string {
+	return " ADD COLUMN "
+}
+
+func (d defaultDialect) alterColumn(tableName string, from *ColumnSchema, to *ColumnSchema) []string {
+	return alterColumnStandard(d, tableName, from, to)
+}
+
+func (d defaultDialect) dropIndex(tableName string, index *IndexSchema) string {
+	return dropIndexStandard(d, tableName, index)
+}
+
+func (d defaultDialect) addUniqueConstraint(tableName string, index *IndexSchema) string {
+	return addUniqueConstraintStandard(d, tableName, index)
+}
+
+func (postgresDialect) addColumnKeyword() string {
+	return " ADD COLUMN "
+}
+
+func (d postgresDialect) alterColumn(tableName string, from *ColumnSchema, to *ColumnSchema) []string {
+	return alterColumnStandard(d, tableName, from, to)
+}
+
+func (d postgresDialect) dropIndex(tableName string, index *IndexSchema) string {
+	return dropIndexStandard(d, tableName, index)
+}
+
+func (d postgresDialect) addUniqueConstraint(tableName string, index *IndexSchema) string {
+	return addUniqueConstraintStandard(d, tableName, index)
+}
+
+func (mysqlDialect) addColumnKeyword() string {
+	return " ADD COLUMN "
+}
+
+func (d mysqlDialect) alterColumn(tableName string, from *ColumnSchema, to *ColumnSchema) []string {
+	var buffer bytes.Buffer
+
+	c := *to
+	c.Unique = false
+
+	buffer.WriteString("ALTER TABLE ")
+	buffer.WriteString(quoteName(d, tableName))
+	buffer.WriteString(" MODIFY COLUMN ")
+	writeColumnDefinition(&buffer, d, &c, false)
+	buffer.WriteString(";\n")
+
+	return []string{buffer.String()}
+}
+
+func (d mysqlDialect) dropIndex(tableName string, index *IndexSchema) string {
+	return "DROP INDEX " + d.quoteIdentifier(index.Name) + " ON " + quoteName(d, tableName) + ";\n"
+}
+
+func (d mysqlDialect) addUniqueConstraint(tableName string, index *IndexSchema) string {
+	return addUniqueConstraintStandard(d, tableName, index)
+}
+
+func (sqliteDialect) addColumnKeyword() string {
+	return " ADD COLUMN "
+}
+
+func (sqliteDialect) alterColumn(tableName string, from *ColumnSchema, to *ColumnSchema) []string {
+	return []string{"-- sqlite does not support ALTER COLUMN, please rebuild " + tableName + "." + to.Name + " manually\n"}
+}
+
+func (d sqliteDialect) dropIndex(tableName string, index *IndexSchema) string {
+	if index.isConstraint {
+		return "-- sqlite can not drop unique constraint " + index.Name + ", please rebuild " + tableName + " manually\n"
+	}
+
+	return "DROP INDEX " + d.quoteIdentifier(index.Name) + ";\n"
+}
+
+// addUniqueConstraint sqlite的dropIndex没有删除唯一约束，回退时不需要重新创建
+func (sqliteDialect) addUniqueConstraint(tableName string, index *IndexSchema) string {
+	return "-- unique constraint " + index.Name + " of " + tableName + " was not dropped\n"
+}
+
+func (sqlServerDialect) addColumnKeyword() string {
+	return " ADD "
+}
+
+func (d sqlServerDialect) alterColumn(tableName string, from *ColumnSchema, to *ColumnSchema) []string {
+	var list []string
+	table := quoteName(d, tableName)
+
+	if !isSameColumnType(from.Type, to.Type) || from.Nullable != to.Nullable {
+		nullable := " NOT NULL"
+
+		if to.Nullable {
+			nullable = " NULL"
+		}
+
+		list = append(list, getAlterColumnPrefix(d, tableName, to)+" "+to.Type+nullable+";\n")
+	}
+
+	if !isSameDefault(from.Default, to.Default) {
+		if from.Default != "" {
+			name := from.defaultName
+
+			if name == "" {
+				name = getIndexName("DF", tableName, from.Name)
+			}
+
+			list = append(list, "ALTER TABLE "+table+" DROP CONSTRAINT "+d.quoteIdentifier(name)+";\n")
+		}
+
+		if to.Default != "" {
+			list = append(list, "ALTER TABLE "+table+" ADD CONSTRAINT "+d.quoteIdentifier(getIndexName("DF", tableName, to.Name))+
+				" DEFAULT "+to.Default+" FOR "+d.quoteIdentifier(to.Name)+";\n")
+		}
+	}
+
+	return list
+}
+
+func (d sqlServerDialect) dropIndex(tableName string, index *IndexSchema) string {
+	if index.isConstraint {
+		return "ALTER TABLE " + quoteName(d, tableName) + " DROP CONSTRAINT " + d.quoteIdentifier(index.Name) + ";\n"
+	}
+
+	return "DROP INDEX " + d.quoteIdentifier(index.Name) + " ON " + quoteName(d, tableName) + ";\n"
+}
+
+func (d sqlServerDialect) addUniqueConstraint(tableName string, index *IndexSchema) string {
+	return addUniqueConstraintStandard(d, tableName, index)
+}
@@ -0,0 +1,170 @@
+package sqlcodegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeColumnType(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"int", "INTEGER", true},
+		{"int4", "integer", true},
+		{"character varying(50)", "VARCHAR(50)", true},
+		{"decimal(10, 2)", "NUMERIC(10,2)", true},
+		{"varchar (20)", "VARCHAR(20)", true},
+		{"double  precision", "float8", true},
+		{"VARCHAR(20)", "VARCHAR(50)", false},
+		{"BIGINT", "INTEGER", false},
+	}
+
+	for _, test := range tests {
+		if same := isSameColumnType(test.a, test.b); same != test.same {
+			t.Errorf("isSameColumnType(%q, %q) = %v, want %v (%q, %q)", test.a, test.b, same, test.same,
+				normalizeColumnType(test.a), normalizeColumnType(test.b))
+		}
+	}
+}
+
+func TestDiffSchema(t *testing.T) {
+	current := &Schema{Tables: []*TableSchema{{
+		Name: "User",
+		Columns: []*ColumnSchema{
+			{Name: "UserID", Type: "INTEGER"},
+			{Name: "Phone", Type: "VARCHAR(20)", Nullable: true},
+		},
+	}}}
+
+	target := &Schema{Tables: []*TableSchema{
+		{
+			Name: "User",
+			Columns: []*ColumnSchema{
+				{Name: "UserID", Type: "INTEGER"},
+				{Name: "UserName", Type: "VARCHAR(50)", Default: "''"},
+			},
+		},
+		{
+			Name:    "Tag",
+			Columns: []*ColumnSchema{{Name: "Name", Type: "VARCHAR(20)"}},
+		},
+	}}
+
+	up, down, err := DiffSchema(current, target, DialectPostgres)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`ALTER TABLE "User" DROP COLUMN "Phone";`,
+		`ALTER TABLE "User" ADD COLUMN "UserName" VARCHAR(50) NOT NULL DEFAULT '';`,
+		`CREATE TABLE "Tag"`,
+	} {
+		if !strings.Contains(up, want) {
+			t.Errorf("up script does not contain %s\n%s", want, up)
+		}
+	}
+
+	for _, want := range []string{
+		`ALTER TABLE "User" ADD COLUMN "Phone" VARCHAR(20) NULL;`,
+		`ALTER TABLE "User" DROP COLUMN "UserName";`,
+		`DROP TABLE "Tag";`,
+	} {
+		if !strings.Contains(down, want) {
+			t.Errorf("down script does not contain %s\n%s", want, down)
+		}
+	}
+
+	// 结构相同时没有任何语句
+	if up, down, _ := DiffSchema(target, target, DialectPostgres); up != "" || down != "" {
+		t.Errorf("diff of the same schema: up %q, down %q", up, down)
+	}
+}
+
+func TestDiffSchemaUniqueConstraint(t *testing.T) {
+	current := &Schema{Tables: []*TableSchema{{
+		Name:    "User",
+		Columns: []*ColumnSchema{{Name: "Email", Type: "VARCHAR(50)"}},
+		Indexes: []*IndexSchema{
+			{Name: "uq_user_email", Columns: []string{"Email"}, Unique: true, isConstraint: true},
+			{Name: "ix_user_email", Columns: []string{"Email"}},
+		},
+	}}}
+
+	target := &Schema{Tables: []*TableSchema{{
+		Name:    "User",
+		Columns: []*ColumnSchema{{Name: "Email", Type: "VARCHAR(50)"}},
+	}}}
+
+	tests := []struct {
+		dialect  Dialect
+		up, down []string
+	}{
+		{
+			DialectPostgres,
+			[]string{`ALTER TABLE "User" DROP CONSTRAINT "uq_user_email";`, `DROP INDEX "ix_user_email";`},
+			[]string{`ALTER TABLE "User" ADD CONSTRAINT "uq_user_email" UNIQUE ("Email");`, `CREATE INDEX "ix_user_email" ON "User" ("Email");`},
+		},
+		{
+			DialectSQLServer,
+			[]string{`ALTER TABLE [User] DROP CONSTRAINT [uq_user_email];`, `DROP INDEX [ix_user_email] ON [User];`},
+			[]string{`ALTER TABLE [User] ADD CONSTRAINT [uq_user_email] UNIQUE ([Email]);`, `CREATE INDEX [ix_user_email] ON [User] ([Email]);`},
+		},
+		{
+			DialectSQLite,
+			[]string{`-- sqlite can not drop unique constraint uq_user_email`},
+			[]string{`-- unique constraint uq_user_email of User was not dropped`},
+		},
+	}
+
+	for _, test := range tests {
+		up, down, err := DiffSchema(current, target, test.dialect)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, want := range test.up {
+			if !strings.Contains(up, want) {
+				t.Errorf("%s: up script does not contain %s\n%s", test.dialect, want, up)
+			}
+		}
+
+		for _, want := range test.down {
+			if !strings.Contains(down, want) {
+				t.Errorf("%s: down script does not contain %s\n%s", test.dialect, want, down)
+			}
+		}
+
+		if strings.Contains(down, "UNIQUE INDEX") {
+			t.Errorf("%s: unique constraint restored as an index\n%s", test.dialect, down)
+		}
+	}
+}
+
+func TestDiffSchemaMySQLIdentityKey(t *testing.T) {
+	target, err := LoadSchema(DialectMySQL, filepath.Join("testdata", "ddl", "ddl.go"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up, _, err := DiffSchema(&Schema{}, target, DialectMySQL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 以自增列开头的索引在CREATE TABLE中声明，不再单独创建
+	if want := "PRIMARY KEY (`PaymentNo`),\n    KEY `idx_Payment_Seq` (`Seq`)\n);"; !strings.Contains(up, want) || strings.Contains(up, "CREATE INDEX `idx_Payment_Seq`") {
+		t.Errorf("up script does not declare KEY idx_Payment_Seq in CREATE TABLE\n%s", up)
+	}
+
+	// 数据库中已有该索引时没有差异
+	if up, down, _ := DiffSchema(target, target, DialectMySQL); up != "" || down != "" {
+		t.Errorf("diff of the same schema: up %q, down %q", up, down)
+	}
+}
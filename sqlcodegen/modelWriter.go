@@ -0,0 +1,479 @@
+package sqlcodegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ModelOptions 由数据库结构生成描述文件的选项
+type ModelOptions struct {
+	PackageName string
+	// CRUD 为true时按主键生成Get、Insert、Update、Delete函数
+	CRUD bool
+}
+
+type modelField struct {
+	name   string
+	goType string
+	tag    string
+	column *ColumnSchema
+}
+
+type modelWriter struct {
+	buffer    bytes.Buffer
+	d         sqlDialect
+	table     *TableSchema
+	typeName  string
+	varName   string
+	fields    []*modelField
+	imports   map[string]bool
+	paramName map[string]string
+}
+
+// GetModelTypeName 返回数据表对应的模型名称，如 user_role 对应 UserRole
+func GetModelTypeName(tableName string) string {
+	if i := strings.LastIndex(tableName, "."); i >= 0 {
+		tableName = tableName[i+1:]
+	}
+
+	return toGoName(tableName)
+}
+
+// WriteModel 按照数据表结构输出描述文件，包含模型、实体与可选的CRUD函数
+func WriteModel(w io.Writer, t *TableSchema, dialect Dialect, opts ModelOptions) error {
+	d, err := getSQLDialect(dialect)
+
+	if err != nil {
+		return err
+	}
+
+	m := &modelWriter{d: d, table: t, imports: make(map[string]bool), paramName: make(map[string]string)}
+	m.typeName = GetModelTypeName(t.Name)
+	m.varName = getEntityName(m.typeName)
+
+	m.addFields()
+
+	packageName := opts.PackageName
+
+	if packageName == "" {
+		packageName = "models"
+	}
+
+	m.buffer.WriteString("package " + packageName + "\n\n")
+	m.writeImports()
+	m.writeModel()
+
+	if opts.CRUD {
+		m.writeFunctions()
+	}
+
+	src, err := format.Source(m.buffer.Bytes())
+
+	if err != nil {
+		return fmt.Errorf("error: format model %v: %v", m.typeName, err)
+	}
+
+	_, err = w.Write(src)
+
+	return err
+}
+
+func (m *modelWriter) addFields() {
+	// 嵌入的sqlcodegen.TableName占用了TableName字段名
+	used := map[string]int{m.typeName: 1, "TableName": 1}
+
+	for _, col := range m.table.Columns {
+		name := toGoName(col.Name)
+
+		if n := used[name]; n > 0 {
+			used[name] = n + 1
+			name += strconv.Itoa(n + 1)
+		}
+
+		used[name] = 1
+
+		field := &modelField{name: name, column: col}
+		field.goType = getModelFieldType(m.d, col)
+
+		if strings.Contains(field.goType, "sql.") {
+			m.imports["database/sql"] = true
+		}
+
+		if strings.Contains(field.goType, "time.") {
+			m.imports["time"] = true
+		}
+
+		var tags []string
+
+		if name != col.Name {
+			tags = append(tags, "name:"+strconv.Quote(col.Name))
+		}
+
+		if m.isPrimaryKey(col.Name) {
+			tags = append(tags, `pk:"true"`)
+		}
+
+		if col.Identity {
+			tags = append(tags, `identity:"true"`)
+		}
+
+		size := getColumnSize(col.Type)
+
+		if size > 0 && getBaseType(field.goType) == "string" {
+			tags = append(tags, `size:"`+strconv.Itoa(size)+`"`)
+		} else {
+			size = 0
+		}
+
+		// 推断的列类型与数据库不同时保留原类型，使gosql ddl与migrate生成相同的结构
+		if !isSameColumnType(m.d.(ddlDialect).columnType(getBaseType(field.goType), size), col.Type) {
+			tags = append(tags, "type:"+strconv.Quote(col.Type))
+		}
+
+		if col.Default != "" && !col.Identity {
+			tags = append(tags, "default:"+strconv.Quote(col.Default))
+		}
+
+		if index := m.getIndex(col.Name, false); index != nil {
+			tags = append(tags, `index:"`+index.Name+`"`)
+		}
+
+		if col.Unique {
+			tags = append(tags, `unique:"true"`)
+		} else if index := m.getIndex(col.Name, true); index != nil {
+			tags = append(tags, `unique:"`+index.Name+`"`)
+		}
+
+		if len(tags) > 0 {
+			field.tag = "`" + strings.Join(tags, " ") + "`"
+		}
+
+		m.fields = append(m.fields, field)
+		m.paramName[col.Name] = getParamName(name, m.varName)
+	}
+}
+
+func (m *modelWriter) isPrimaryKey(columnName string) bool {
+	for _, pk := range m.table.PrimaryKey {
+		if pk == columnName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getIndex 返回包含该列的第一个索引，一个字段只能声明一个普通索引与一个唯一索引
+func (m *modelWriter) getIndex(columnName string, unique bool) *IndexSchema {
+	for _, index := range m.table.Indexes {
+		if index.Unique != unique {
+			continue
+		}
+
+		for _, c := range index.Columns {
+			if c == columnName {
+				return index
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *modelWriter) writeImports() {
+	m.buffer.WriteString("import (\n")
+
+	for _, path := range []string{"database/sql", "time", ""} {
+		if path == "" {
+			m.buffer.WriteString("\n")
+			continue
+		}
+
+		if m.imports[path] {
+			m.buffer.WriteString("\t" + strconv.Quote(path) + "\n")
+		}
+	}
+
+	m.buffer.WriteString("\t\"github.com/YiCodes/gosql/sqlcodegen\"\n)\n\n")
+}
+
+func (m *modelWriter) writeModel() {
+	m.buffer.WriteString("// " + m.typeName + " 数据表" + m.table.Name + "\n")
+	m.buffer.WriteString("type " + m.typeName + " struct {\n")
+	m.buffer.WriteString("\tsqlcodegen.TableName `tableName:\"" + m.table.Name + "\"`\n")
+
+	for _, f := range m.fields {
+		m.buffer.WriteString("\t" + f.name + " " + f.goType + " " + f.tag + "\n")
+	}
+
+	m.buffer.WriteString("}\n\nvar (\n\t" + m.varName + " " + m.typeName + "\n)\n")
+}
+
+func (m *modelWriter) writeFunctions() {
+	var keys, values []*modelField
+
+	for _, f := range m.fields {
+		if m.isPrimaryKey(f.column.Name) {
+			keys = append(keys, f)
+		} else if !f.column.Identity {
+			values = append(values, f)
+		}
+	}
+
+	m.buffer.WriteString("\n// Insert" + m.typeName + " 插入一条记录\n")
+	m.buffer.WriteString("func Insert" + m.typeName + "() {\n")
+	m.buffer.WriteString("\tsqlcodegen.InsertAll(" + m.varName + ")\n}\n")
+
+	if len(keys) == 0 {
+		return
+	}
+
+	where := m.getKeyCondition(keys)
+
+	m.buffer.WriteString("\n// Get" + m.typeName + " 根据主键获取一条记录\n")
+	m.buffer.WriteString("func Get" + m.typeName + "(" + m.getParamList(keys) + ") {\n")
+	m.buffer.WriteString("\tsqlcodegen.From(" + m.varName + ")\n")
+	m.buffer.WriteString("\tsqlcodegen.SelectAll(" + m.varName + ")\n")
+	m.buffer.WriteString("\tsqlcodegen.Where(" + where + ")\n")
+	m.buffer.WriteString("\tsqlcodegen.SetReturnType(sqlcodegen.ReturnRecord)\n}\n")
+
+	if len(values) > 0 {
+		m.buffer.WriteString("\n// Update" + m.typeName + " 根据主键更新一条记录\n")
+		m.buffer.WriteString("func Update" + m.typeName + "(" + m.getParamList(append(append([]*modelField{}, keys...), values...)) + ") {\n")
+		m.buffer.WriteString("\tsqlcodegen.From(" + m.varName + ")\n")
+
+		for _, f := range values {
+			m.buffer.WriteString("\tsqlcodegen.Update(" + m.varName + "." + f.name + ", " + m.paramName[f.column.Name] + ")\n")
+		}
+
+		m.buffer.WriteString("\tsqlcodegen.Where(" + where + ")\n}\n")
+	}
+
+	m.buffer.WriteString("\n// Delete" + m.typeName + " 根据主键删除一条记录\n")
+	m.buffer.WriteString("func Delete" + m.typeName + "(" + m.getParamList(keys) + ") {\n")
+	m.buffer.WriteString("\tsqlcodegen.Delete(" + m.varName + ")\n")
+	m.buffer.WriteString("\tsqlcodegen.Where(" + where + ")\n}\n")
+}
+
+func (m *modelWriter) getParamList(fields []*modelField) string {
+	list := make([]string, len(fields))
+
+	for i, f := range fields {
+		list[i] = m.paramName[f.column.Name] + " " + f.goType
+	}
+
+	return strings.Join(list, ", ")
+}
+
+func (m *modelWriter) getKeyCondition(keys []*modelField) string {
+	list := make([]string, len(keys))
+
+	for i, f := range keys {
+		list[i] = m.varName + "." + f.name + " == " + m.paramName[f.column.Name]
+	}
+
+	return strings.Join(list, " && ")
+}
+
+var goNameInitialisms = map[string]bool{
+	"ID": true, "URL": true, "URI": true, "UUID": true, "IP": true, "HTTP": true,
+	"JSON": true, "XML": true, "SQL": true, "API": true, "UID": true, "GUID": true,
+}
+
+// toGoName 将数据库中的名称转换为导出的Go标识符，如 user_id 转换为 UserID
+func toGoName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var result strings.Builder
+
+	for _, part := range parts {
+		if upper := strings.ToUpper(part); goNameInitialisms[upper] {
+			result.WriteString(upper)
+			continue
+		}
+
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		result.WriteString(string(runes))
+	}
+
+	s := result.String()
+
+	if s == "" || !unicode.IsLetter([]rune(s)[0]) {
+		s = "X" + s
+	}
+
+	return s
+}
+
+func lowerFirstName(name string) string {
+	runes := []rune(name)
+	i := 0
+
+	for i < len(runes) && unicode.IsUpper(runes[i]) {
+		i++
+	}
+
+	// 保留首字母缩写之后单词的大写，如 IDCard 转换为 idCard
+	if i > 1 && i < len(runes) {
+		i--
+	}
+
+	return strings.ToLower(string(runes[:i])) + string(runes[i:])
+}
+
+func getEntityName(typeName string) string {
+	name := lowerFirstName(typeName)
+
+	if token.Lookup(name).IsKeyword() || name == "sqlcodegen" {
+		name += "Entity"
+	}
+
+	return name
+}
+
+func getParamName(fieldName string, varName string) string {
+	name := lowerFirstName(fieldName)
+
+	if token.Lookup(name).IsKeyword() || name == varName || name == "sqlcodegen" {
+		name += "Value"
+	}
+
+	return name
+}
+
+// getColumnSize 返回列类型中的长度，如 VARCHAR(50) 返回50
+func getColumnSize(columnType string) int {
+	start := strings.Index(columnType, "(")
+	end := strings.Index(columnType, ")")
+
+	if start < 0 || end < start {
+		return 0
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(columnType[start+1 : end]))
+
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
+
+var nullTypes = map[string]string{
+	"string":    "sql.NullString",
+	"int64":     "sql.NullInt64",
+	"int32":     "sql.NullInt32",
+	"int16":     "sql.NullInt16",
+	"byte":      "sql.NullByte",
+	"float64":   "sql.NullFloat64",
+	"bool":      "sql.NullBool",
+	"time.Time": "sql.NullTime",
+}
+
+func getModelFieldType(d sqlDialect, col *ColumnSchema) string {
+	goType := getColumnGoType(d, col.Type)
+
+	if !col.Nullable || col.Identity {
+		return goType
+	}
+
+	if nullType, ok := nullTypes[goType]; ok {
+		return nullType
+	}
+
+	return "sql.Null[" + goType + "]"
+}
+
+// getColumnGoType 返回列类型对应的Go类型，未知的类型使用string
+func getColumnGoType(d sqlDialect, columnType string) string {
+	t := normalizeColumnType(columnType)
+	unsigned := strings.Contains(t, "UNSIGNED")
+	base := t
+
+	if i := strings.IndexAny(base, "( "); i >= 0 {
+		base = base[:i]
+	}
+
+	if alias, ok := columnTypeAliases[base]; ok {
+		base = alias
+	}
+
+	_, isMySQL := d.(mysqlDialect)
+	_, isSQLite := d.(sqliteDialect)
+	_, isSQLServer := d.(sqlServerDialect)
+
+	switch base {
+	case "BIGINT", "BIGSERIAL", "SERIAL8":
+		if unsigned {
+			return "uint64"
+		}
+
+		return "int64"
+	case "INTEGER", "MEDIUMINT", "SERIAL", "SERIAL4":
+		if isSQLite {
+			return "int64"
+		}
+
+		if unsigned {
+			return "uint32"
+		}
+
+		return "int32"
+	case "SMALLINT", "SMALLSERIAL", "YEAR":
+		if unsigned {
+			return "uint16"
+		}
+
+		return "int16"
+	case "TINYINT":
+		if isMySQL && t == "TINYINT(1)" {
+			return "bool"
+		}
+
+		if unsigned || isSQLServer {
+			return "byte"
+		}
+
+		return "int8"
+	case "BOOLEAN", "BIT":
+		if isMySQL && t != "BIT" && t != "BIT(1)" {
+			return "[]byte"
+		}
+
+		return "bool"
+	case "REAL":
+		if isSQLite {
+			return "float64"
+		}
+
+		return "float32"
+	case "FLOAT":
+		if isMySQL {
+			return "float32"
+		}
+
+		return "float64"
+	case "DOUBLE", "NUMERIC", "MONEY", "SMALLMONEY":
+		return "float64"
+	case "DATE", "TIME", "DATETIME", "DATETIME2", "SMALLDATETIME", "DATETIMEOFFSET",
+		"TIMESTAMP", "TIMESTAMPTZ", "TIMETZ":
+		if isSQLServer && base == "TIMESTAMP" {
+			return "[]byte"
+		}
+
+		return "time.Time"
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BYTEA", "BINARY", "VARBINARY", "IMAGE", "ROWVERSION":
+		return "[]byte"
+	}
+
+	return "string"
+}
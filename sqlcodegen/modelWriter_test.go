@@ -0,0 +1,182 @@
+package sqlcodegen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestToGoName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"user_id", "UserID"},
+		{"user_role", "UserRole"},
+		{"UserName", "UserName"},
+		{"order-items", "OrderItems"},
+		{"api_url", "APIURL"},
+		{"json data", "JSONData"},
+		{"2fa_code", "X2faCode"},
+		{"_", "X"},
+	}
+
+	for _, test := range tests {
+		if got := toGoName(test.name); got != test.want {
+			t.Errorf("toGoName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	if got := GetModelTypeName("dbo.user_role"); got != "UserRole" {
+		t.Errorf("GetModelTypeName(dbo.user_role) = %q, want UserRole", got)
+	}
+}
+
+func TestGetColumnGoType(t *testing.T) {
+	tests := []struct {
+		dialect    Dialect
+		columnType string
+		want       string
+	}{
+		{DialectMySQL, "bigint unsigned", "uint64"},
+		{DialectMySQL, "int(11)", "int32"},
+		{DialectMySQL, "tinyint(1)", "bool"},
+		{DialectMySQL, "tinyint(4)", "int8"},
+		{DialectMySQL, "bit(8)", "[]byte"},
+		{DialectMySQL, "float", "float32"},
+		{DialectMySQL, "datetime", "time.Time"},
+		{DialectMySQL, "longblob", "[]byte"},
+		{DialectPostgres, "integer", "int32"},
+		{DialectPostgres, "bigserial", "int64"},
+		{DialectPostgres, "boolean", "bool"},
+		{DialectPostgres, "double precision", "float64"},
+		{DialectPostgres, "timestamp with time zone", "time.Time"},
+		{DialectPostgres, "character varying(50)", "string"},
+		{DialectPostgres, "uuid", "string"},
+		{DialectSQLite, "INTEGER", "int64"},
+		{DialectSQLite, "REAL", "float64"},
+		{DialectSQLServer, "tinyint", "byte"},
+		{DialectSQLServer, "timestamp", "[]byte"},
+		{DialectSQLServer, "datetime2", "time.Time"},
+		{DialectSQLServer, "nvarchar(max)", "string"},
+	}
+
+	for _, test := range tests {
+		d, err := getSQLDialect(test.dialect)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := getColumnGoType(d, test.columnType); got != test.want {
+			t.Errorf("%s: getColumnGoType(%q) = %q, want %q", test.dialect, test.columnType, got, test.want)
+		}
+	}
+}
+
+func TestWriteModel(t *testing.T) {
+	table := &TableSchema{
+		Name: "user_role",
+		Columns: []*ColumnSchema{
+			{Name: "id", Type: "BIGINT", Identity: true},
+			{Name: "user_id", Type: "BIGINT"},
+			{Name: "UserID", Type: "VARCHAR(20)", Nullable: true},
+			{Name: "table_name", Type: "VARCHAR(50)", Default: "'users'"},
+			{Name: "type", Type: "INTEGER"},
+		},
+		PrimaryKey: []string{"id"},
+		Indexes: []*IndexSchema{
+			{Name: "ix_user_role_user", Columns: []string{"user_id"}},
+			{Name: "uq_user_role_type", Columns: []string{"type"}, Unique: true},
+		},
+	}
+
+	var buffer bytes.Buffer
+
+	if err := WriteModel(&buffer, table, DialectPostgres, ModelOptions{PackageName: "models", CRUD: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 忽略gofmt对齐字段产生的空白
+	code := strings.Join(strings.Fields(buffer.String()), " ")
+
+	for _, want := range []string{
+		"package models",
+		`"database/sql"`,
+		"type UserRole struct {",
+		"sqlcodegen.TableName `tableName:\"user_role\"`",
+		"ID int64 `name:\"id\" pk:\"true\" identity:\"true\"`",
+		// 重名的字段加上序号，TableName 已被嵌入的sqlcodegen.TableName占用
+		"UserID int64 `name:\"user_id\" index:\"ix_user_role_user\"`",
+		"UserID2 sql.NullString `name:\"UserID\" size:\"20\"`",
+		"TableName2 string `name:\"table_name\" size:\"50\" default:\"'users'\"`",
+		"Type int32 `name:\"type\" unique:\"uq_user_role_type\"`",
+		"userRole UserRole",
+		"func InsertUserRole() { sqlcodegen.InsertAll(userRole) }",
+		"func GetUserRole(id int64) {",
+		"sqlcodegen.Where(userRole.ID == id)",
+		// 参数名为Go关键字时加上Value后缀
+		"func UpdateUserRole(id int64, userID int64, userID2 sql.NullString, tableName2 string, typeValue int32) {",
+		"sqlcodegen.Update(userRole.Type, typeValue)",
+		"func DeleteUserRole(id int64) {",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("model does not contain %s\n%s", want, buffer.String())
+		}
+	}
+
+	buffer.Reset()
+
+	if err := WriteModel(&buffer, table, DialectPostgres, ModelOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := buffer.String(); !strings.HasPrefix(code, "package models\n") || strings.Contains(code, "func ") {
+		t.Errorf("model without -crud contains functions\n%s", code)
+	}
+}
+
+// TestWriteModelRoundTrip 生成的模型重新读取后与原数据库结构相同
+func TestWriteModelRoundTrip(t *testing.T) {
+	table := &TableSchema{
+		Name: "orders",
+		Columns: []*ColumnSchema{
+			{Name: "order_id", Type: "INTEGER", Identity: true},
+			{Name: "user_id", Type: "VARCHAR(20)"},
+			{Name: "amount", Type: "NUMERIC(10,2)", Default: "0"},
+			{Name: "note", Type: "TEXT", Nullable: true},
+			{Name: "created", Type: "TIMESTAMP"},
+		},
+		PrimaryKey: []string{"order_id"},
+		Indexes:    []*IndexSchema{{Name: "ix_orders_user", Columns: []string{"user_id"}}},
+	}
+
+	var buffer bytes.Buffer
+
+	if err := WriteModel(&buffer, table, DialectPostgres, ModelOptions{CRUD: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "orders.go")
+
+	if err := ioutil.WriteFile(fileName, buffer.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := LoadSchema(DialectPostgres, fileName)
+
+	if err != nil {
+		t.Fatalf("%v\n%s", err, buffer.String())
+	}
+
+	up, down, err := DiffSchema(&Schema{Tables: []*TableSchema{table}}, schema, DialectPostgres)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if up != "" || down != "" {
+		t.Errorf("loaded model differs from the table\nup:\n%s\ndown:\n%s\n%s", up, down, buffer.String())
+	}
+}
@@ -0,0 +1,281 @@
+package sqlcodegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// GetModelFiles 返回目录中的描述文件，忽略_test.go、生成的代码以及不满足编译约束的文件
+func GetModelFiles(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+
+	var result []string
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		if match, err := build.Default.MatchFile(dir, name); err != nil {
+			return nil, err
+		} else if !match {
+			continue
+		}
+
+		fileName := filepath.Join(dir, name)
+
+		// 语法错误留到编译时报告
+		file, _ := parser.ParseFile(fset, fileName, nil, parser.PackageClauseOnly|parser.ParseComments)
+
+		if file != nil && ast.IsGenerated(file) {
+			continue
+		}
+
+		result = append(result, fileName)
+	}
+
+	return result, nil
+}
+
+// GeneratedFile 生成的文件，Name为输出目录中的文件名
+type GeneratedFile struct {
+	Name    string
+	Content []byte
+}
+
+// CompilePackage 编译srcDir中的所有描述文件，模型、实体与结果类型在文件之间共享。
+// 默认在outDir中为每个描述文件生成同名文件，opts.SingleFile不为空时全部生成到outDir中的这一个文件；
+// 出现错误时不写入任何文件
+func CompilePackage(srcDir string, outDir string, opts Options) error {
+	files, err := GeneratePackage(srcDir, opts)
+
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if err := ioutil.WriteFile(filepath.Join(outDir, f.Name), f.Content, 0666); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GeneratePackage 与CompilePackage相同，但不写入文件，返回生成的代码
+func GeneratePackage(srcDir string, opts Options) ([]*GeneratedFile, error) {
+	fileNames, err := GetModelFiles(srcDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fileNames) == 0 {
+		return nil, fmt.Errorf("no description files in %s", srcDir)
+	}
+
+	context, err := loadModelPackage(fileNames, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*GeneratedFile
+
+	if opts.SingleFile != "" {
+		var buffer bytes.Buffer
+
+		genModelFiles(context, &buffer, context.files...)
+
+		result = append(result, &GeneratedFile{Name: opts.SingleFile, Content: buffer.Bytes()})
+	} else {
+		for _, file := range context.files {
+			var buffer bytes.Buffer
+
+			genModelFiles(context, &buffer, file)
+
+			fileName := filepath.Base(context.fset.Position(file.Pos()).Filename)
+			result = append(result, &GeneratedFile{Name: fileName, Content: buffer.Bytes()})
+		}
+	}
+
+	if !context.diagnostics.HasErrors() {
+		checkGeneratedCode(context, srcDir, result)
+	}
+
+	if err := context.finish(opts.Warn); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// loadModelPackage 解析并检查同一个包中的描述文件，返回可以用于生成代码的parseContext
+func loadModelPackage(fileNames []string, opts Options) (*parseContext, error) {
+	context := &parseContext{}
+	context.entity = make(map[string]*table)
+	context.resultTypes = make(map[string]*resultType)
+	context.fset = token.NewFileSet()
+	context.useContext = !opts.NoContext
+
+	if opts.SQLBuilder == nil {
+		sqlBuilder, err := NewSQLBuilder(opts.Dialect)
+
+		if err != nil {
+			return nil, err
+		}
+
+		context.sqlBuilder = sqlBuilder
+	} else {
+		context.sqlBuilder = opts.SQLBuilder
+	}
+
+	files, err := parseModelFiles(context, fileNames)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := typeCheckPackage(context, files); err != nil {
+		return nil, err
+	}
+
+	if context.diagnostics.HasErrors() {
+		return nil, context.finish(opts.Warn)
+	}
+
+	context.files = files
+	context.packageName = getPackageName(context)
+
+	return context, nil
+}
+
+// parseModelFiles 解析描述文件，先注册所有文件中的模型，再注册实体，实体可以使用其他文件中定义的模型
+func parseModelFiles(context *parseContext, srcFileNames []string) ([]*ast.File, error) {
+	var files []*ast.File
+
+	for _, srcFileName := range srcFileNames {
+		file, err := context.parseFile(srcFileName, parser.ParseComments)
+
+		if err != nil {
+			if _, ok := err.(Diagnostics); !ok {
+				return nil, err
+			}
+
+			context.addError(err)
+			continue
+		}
+
+		if len(files) > 0 && file.Name.Name != files[0].Name.Name {
+			context.addError(context.newError(file.Name, "package %s differs from package %s in %s",
+				file.Name.Name, files[0].Name.Name, filepath.Base(context.fset.Position(files[0].Pos()).Filename)))
+			continue
+		}
+
+		files = append(files, file)
+	}
+
+	if context.diagnostics.HasErrors() {
+		context.diagnostics.sort()
+
+		return nil, context.diagnostics
+	}
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			if inst, ok := decl.(*ast.GenDecl); ok && inst.Tok == token.TYPE {
+				if err := addTable(context, inst); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			if inst, ok := decl.(*ast.GenDecl); ok && inst.Tok == token.VAR {
+				if err := addEntity(context, inst); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// getPackageName 返回生成代码的包名，默认与描述文件相同，可以在任意文件的init中通过SetPackageName指定
+func getPackageName(context *parseContext) string {
+	packName := context.files[0].Name.Name
+
+	var setExpr *ast.CallExpr
+
+	for _, file := range context.files {
+		for _, decl := range file.Decls {
+			inst, ok := decl.(*ast.FuncDecl)
+
+			if !ok || inst.Name.Name != "init" {
+				continue
+			}
+
+			for callExpr := range getCallExprList(inst) {
+				fun := callExpr.Fun.(*ast.SelectorExpr)
+
+				if fun.Sel.Name != "SetPackageName" {
+					context.warn(callExpr, "unsupported %s in init, ignored", types.ExprString(callExpr.Fun))
+					continue
+				}
+
+				lit, ok := callExpr.Args[0].(*ast.BasicLit)
+
+				if !ok ||
+					!(strings.HasPrefix(lit.Value, "\"") && strings.HasSuffix(lit.Value, "\"")) {
+					context.addError(newArgError(context, callExpr))
+					continue
+				}
+
+				name := lit.Value[1 : len(lit.Value)-1]
+
+				if setExpr != nil && name != packName {
+					context.addError(context.newError(callExpr, "package name %s differs from %s set at %s",
+						name, packName, context.fset.Position(setExpr.Pos())))
+					continue
+				}
+
+				packName = name
+				setExpr = callExpr
+			}
+		}
+	}
+
+	return packName
+}
+
+// inFiles 判断node是否定义在files中
+func inFiles(context *parseContext, node ast.Node, files []*ast.File) bool {
+	nodeFile := context.fset.File(node.Pos())
+
+	for _, file := range files {
+		if context.fset.File(file.Pos()) == nodeFile {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package sqlcodegen
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestCompilePackage testdata/shared中的模型、实体与结果类型在多个描述文件之间共享
+func TestCompilePackage(t *testing.T) {
+	outDir := t.TempDir()
+
+	if err := CompilePackage(filepath.Join("testdata", "shared"), outDir, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := ioutil.ReadDir(outDir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	var code strings.Builder
+
+	for _, info := range infos {
+		names = append(names, info.Name())
+
+		content, err := ioutil.ReadFile(filepath.Join(outDir, info.Name()))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// SetPackageName写在models.go的init中，对所有文件生效
+		if f, err := parser.ParseFile(token.NewFileSet(), info.Name(), content, parser.PackageClauseOnly); err != nil || f.Name.Name != "gen" {
+			t.Errorf("%s is not in package gen\n%s", info.Name(), content)
+		}
+
+		code.Write(content)
+	}
+
+	// 生成的代码与不满足编译约束的文件不是描述文件
+	sort.Strings(names)
+
+	if got := strings.Join(names, " "); got != "models.go orders.go users.go" {
+		t.Errorf("generated files %s, want models.go orders.go users.go", got)
+	}
+
+	checkSharedCode(t, code.String())
+}
+
+func TestCompilePackageSingleFile(t *testing.T) {
+	outDir := t.TempDir()
+
+	if err := CompilePackage(filepath.Join("testdata", "shared"), outDir, Options{SingleFile: "shared.go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if infos, _ := ioutil.ReadDir(outDir); len(infos) != 1 {
+		t.Fatalf("got %d files, want shared.go", len(infos))
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(outDir, "shared.go"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkSharedCode(t, string(content))
+}
+
+func checkSharedCode(t *testing.T, code string) {
+	t.Helper()
+
+	for _, want := range []string{
+		"type User struct",
+		"type Order struct",
+		"type UserOrder struct",
+		"func GetUser(",
+		"func GetUserOrders(ctx context.Context, db sqlutil.DbObject, userID string) ([]*UserOrder, error)",
+		"func GetLargeOrders(ctx context.Context, db sqlutil.DbObject, amount int64) ([]*UserOrder, error)",
+	} {
+		if n := strings.Count(code, want); n != 1 {
+			t.Errorf("generated code contains %s %d times, want once\n%s", want, n, code)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package sqlcodegen
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+type procedureSQLBuilder interface {
+	writeProcedureStatement(stmt *SQLProcedureStatement) bool
+	supportsOutParameter() bool
+}
+
+func genProcedureFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
+	var procExpr *ast.CallExpr
+	var selectExpr *ast.CallExpr
+	var isSelectAll bool
+	var returnTypeFlag ReturnType
+	var chanBufferSize int
+	var resultTypeName string
+
+	for callExpr := range getCallExprList(funcDecl) {
+		fun := callExpr.Fun.(*ast.SelectorExpr)
+
+		switch fun.Sel.Name {
+		case "ExecProcedure":
+			procExpr = callExpr
+		case "Select":
+			selectExpr = callExpr
+		case "SelectAll":
+			selectExpr = callExpr
+			isSelectAll = true
+		case "SetResultTypeName":
+			if len(callExpr.Args) != 1 {
+				return newArgError(context, callExpr)
+			}
+
+			lit, ok := callExpr.Args[0].(*ast.BasicLit)
+
+			if !ok || lit.Kind != token.STRING {
+				return newArgError(context, callExpr)
+			}
+
+			resultTypeName = getBasicLitValue(lit)
+		case "SetReturnType":
+			t, err := astToReturnType(context, callExpr)
+
+			if err != nil {
+				return err
+			}
+
+			returnTypeFlag = t
+		case "SetChannelBufferSize":
+			size, err := astToChannelBufferSize(context, callExpr)
+
+			if err != nil {
+				return err
+			}
+
+			chanBufferSize = size
+		}
+	}
+
+	procStmt, err := astToSQLProcedureStatement(context, funcDecl, procExpr)
+
+	if err != nil {
+		return err
+	}
+
+	builder, ok := context.sqlBuilder.(procedureSQLBuilder)
+
+	if !ok {
+		return newUnsupportedError(context, procExpr)
+	}
+
+	var written bool
+
+	query, err := buildSQLQuery(context, funcDecl, "query", nil, getProcedureStmtSqlParamList(procStmt),
+		func(SQLBuilder) { written = builder.writeProcedureStatement(procStmt) })
+
+	if err != nil {
+		return err
+	}
+
+	if !written {
+		return newUnsupportedError(context, procExpr)
+	}
+
+	if returnTypeFlag == ReturnDefault {
+		if selectExpr != nil {
+			returnTypeFlag = ReturnRecordSet
+		} else {
+			returnTypeFlag = ReturnExecResult
+		}
+	}
+
+	if returnTypeFlag == ReturnExecResult {
+		if selectExpr != nil {
+			return newArgError(context, selectExpr)
+		}
+
+		funcResultList := []*ast.Field{newASTField(newASTRefExpr("sql.Result"), "")}
+
+		genMethodBegin(context, funcDecl.Name.Name, funcDecl.Type.Params.List, funcResultList, funcDecl.Doc)
+
+		genQueryDeclaration(context, query)
+		genExec(context, query)
+
+		genMethodEnd(context)
+
+		return nil
+	}
+
+	if selectExpr == nil {
+		return newArgError(context, procExpr)
+	}
+
+	selectStmt, err := astToSQLSelectStatement(context, selectExpr, isSelectAll)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := getSelectResultType(context, funcDecl, selectStmt, resultTypeName)
+
+	if err != nil {
+		return err
+	}
+
+	funcReturnList, returnElementType, err := getRowsReturnList(context, selectExpr, returnTypeFlag, result, false)
+
+	if err != nil {
+		return err
+	}
+
+	if err := genRowsResultType(context, funcDecl, returnTypeFlag, result); err != nil {
+		return err
+	}
+
+	genRowsMethodBegin(context, funcDecl, returnTypeFlag, funcReturnList)
+	genQueryDeclaration(context, query)
+	genQueryRows(context, query, returnTypeFlag, result, returnElementType, chanBufferSize, false)
+	genMethodEnd(context)
+
+	return nil
+}
+
+func astToSQLProcedureStatement(context *parseContext, funcDecl *ast.FuncDecl, procExpr *ast.CallExpr) (*SQLProcedureStatement, error) {
+	if len(procExpr.Args) == 0 {
+		return nil, newArgError(context, procExpr)
+	}
+
+	lit, ok := procExpr.Args[0].(*ast.BasicLit)
+
+	if !ok || lit.Kind != token.STRING {
+		return nil, newArgError(context, procExpr)
+	}
+
+	procStmt := &SQLProcedureStatement{name: getBasicLitValue(lit)}
+	paramNames := getFuncParamNames(funcDecl)
+
+	for _, arg := range procExpr.Args[1:] {
+		if callExpr, ok := arg.(*ast.CallExpr); ok {
+			outExpr, err := astToSQLOutParameter(context, funcDecl, callExpr)
+
+			if err != nil {
+				return nil, err
+			}
+
+			procStmt.args = append(procStmt.args, outExpr)
+			continue
+		}
+
+		sqlExpr, err := astToSQLExpression(arg, context, paramNames)
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch sqlExpr.(type) {
+		case *SQLParameterExpression, *SQLLiteralExpression:
+		default:
+			return nil, newArgError(context, arg)
+		}
+
+		procStmt.args = append(procStmt.args, sqlExpr)
+	}
+
+	return procStmt, nil
+}
+
+func astToSQLOutParameter(context *parseContext, funcDecl *ast.FuncDecl, callExpr *ast.CallExpr) (*SQLParameterExpression, error) {
+	fun, ok := callExpr.Fun.(*ast.SelectorExpr)
+
+	if !ok || fun.Sel.Name != "Out" || len(callExpr.Args) != 1 {
+		return nil, newArgError(context, callExpr)
+	}
+
+	ident, ok := callExpr.Args[0].(*ast.Ident)
+
+	if !ok {
+		return nil, newArgError(context, callExpr)
+	}
+
+	index, ok := getFuncParamNames(funcDecl)[ident.Name]
+
+	if !ok {
+		return nil, newArgError(context, callExpr)
+	}
+
+	if _, ok := funcDecl.Type.Params.List[index].Type.(*ast.StarExpr); !ok {
+		return nil, newArgError(context, callExpr)
+	}
+
+	// MySQL与PostgreSQL的驱动不支持sql.Out，生成的代码可以编译但运行时总是失败
+	if builder, ok := context.sqlBuilder.(procedureSQLBuilder); ok && !builder.supportsOutParameter() {
+		return nil, context.newError(callExpr, "%s is not supported by the dialect, its driver cannot read output parameters with sql.Out",
+			types.ExprString(callExpr.Fun))
+	}
+
+	return &SQLParameterExpression{name: ident.Name, isOut: true}, nil
+}
@@ -0,0 +1,222 @@
+package sqlcodegen
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+type sqlQuery struct {
+	name      string
+	text      string
+	paramList []*SQLParameterExpression
+	dynamic   *dynamicSQL
+	bindType  string
+}
+
+type dynamicSQL struct {
+	prefix       string
+	prefixParams []*SQLParameterExpression
+	conditions   []*dynamicCondition
+	suffix       string
+	suffixParams []*SQLParameterExpression
+}
+
+type dynamicCondition struct {
+	text       string
+	paramList  []*SQLParameterExpression
+	isOptional bool
+}
+
+type dynamicSQLBuilder interface {
+	setDynamic(dynamic bool)
+	getBindType() string
+	getDynamicSQL() *dynamicSQL
+}
+
+func buildSQLQuery(context *parseContext, node ast.Node, name string, where SQLExpression, paramList []*SQLParameterExpression, write func(builder SQLBuilder)) (*sqlQuery, error) {
+	q := &sqlQuery{name: name}
+	builder := context.sqlBuilder
+
+	if _, ok := where.(*SQLDynamicWhereExpression); !ok {
+		builder.Reset()
+		write(builder)
+		q.text = builder.String()
+		q.paramList = builder.GetInvokeParameterList(paramList)
+
+		return q, nil
+	}
+
+	dynamicBuilder, ok := builder.(dynamicSQLBuilder)
+
+	if !ok {
+		return nil, newUnsupportedError(context, node)
+	}
+
+	dynamicBuilder.setDynamic(true)
+	defer dynamicBuilder.setDynamic(false)
+
+	builder.Reset()
+	write(builder)
+	q.dynamic = dynamicBuilder.getDynamicSQL()
+	q.bindType = dynamicBuilder.getBindType()
+
+	return q, nil
+}
+
+func genQueryDeclaration(context *parseContext, q *sqlQuery) {
+	generator := context.generator
+
+	if q.dynamic == nil {
+		generator.writeConstDeclaration(q.name, q.text)
+		return
+	}
+
+	generator.writeLine(q.name, " := sqlutil.NewQuery(", q.bindType, ")")
+	genQueryWrite(context, q.name+".Write", q.dynamic.prefix, q.dynamic.prefixParams)
+
+	for _, c := range q.dynamic.conditions {
+		if c.isOptional && len(c.paramList) > 0 {
+			generator.write("if ")
+
+			for i, p := range c.paramList {
+				if i > 0 {
+					generator.write(" && ")
+				}
+
+				generator.write("!sqlutil.IsZero(")
+				generator.write(p.name)
+				generator.write(")")
+			}
+
+			generator.beginBlock()
+			genQueryWrite(context, q.name+".Where", c.text, c.paramList)
+			generator.endBlock()
+		} else {
+			genQueryWrite(context, q.name+".Where", c.text, c.paramList)
+		}
+	}
+
+	generator.writeLine(q.name, ".EndWhere()")
+	genQueryWrite(context, q.name+".Write", q.dynamic.suffix, q.dynamic.suffixParams)
+}
+
+func genQueryWrite(context *parseContext, method string, text string, paramList []*SQLParameterExpression) {
+	if text == "" {
+		return
+	}
+
+	generator := context.generator
+
+	generator.write(method)
+	generator.write("(")
+	generator.write(strconv.Quote(text))
+
+	for _, p := range paramList {
+		generator.write(", ")
+		generator.write(p.getArgCode())
+	}
+
+	generator.writeLine(")")
+}
+
+// getQueryTextCode 返回执行查询时SQL文本的代码
+func getQueryTextCode(q *sqlQuery) string {
+	if q.dynamic != nil {
+		return q.name + ".String()"
+	}
+
+	return q.name
+}
+
+// getQueryArgsCode 返回执行查询时参数的代码，以", "开头，没有参数时返回空字符串
+func getQueryArgsCode(q *sqlQuery) string {
+	if q.dynamic != nil {
+		return ", " + q.name + ".Args()..."
+	}
+
+	var code strings.Builder
+
+	for _, p := range q.paramList {
+		code.WriteString(", ")
+		code.WriteString(p.getArgCode())
+	}
+
+	return code.String()
+}
+
+func hasOptionalExpression(expr SQLExpression) bool {
+	switch inst := expr.(type) {
+	case *SQLOptionalExpression, *SQLDynamicWhereExpression:
+		return true
+	case *SQLBinaryExpression:
+		return hasOptionalExpression(inst.left) || hasOptionalExpression(inst.right)
+	case *SQLParenthesisExpression:
+		return hasOptionalExpression(inst.target)
+	}
+
+	return false
+}
+
+func hasListParameter(expr SQLExpression) bool {
+	for _, p := range getSqlParamListFromExpression(expr) {
+		if p.isList {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasNullableCompareExpression(expr SQLExpression) bool {
+	switch inst := expr.(type) {
+	case *SQLNullableCompareExpression:
+		return true
+	case *SQLOptionalExpression:
+		return hasNullableCompareExpression(inst.target)
+	case *SQLBinaryExpression:
+		return hasNullableCompareExpression(inst.left) || hasNullableCompareExpression(inst.right)
+	case *SQLParenthesisExpression:
+		return hasNullableCompareExpression(inst.target)
+	}
+
+	return false
+}
+
+func isDynamicExpression(expr SQLExpression) bool {
+	return hasOptionalExpression(expr) || hasListParameter(expr) || hasNullableCompareExpression(expr)
+}
+
+func getAndConditionList(expr SQLExpression) []SQLExpression {
+	if binExpr, ok := expr.(*SQLBinaryExpression); ok && binExpr.op == "&&" {
+		return append(getAndConditionList(binExpr.left), getAndConditionList(binExpr.right)...)
+	}
+
+	return []SQLExpression{expr}
+}
+
+func toDynamicWhereExpression(where SQLExpression) (SQLExpression, bool) {
+	if !isDynamicExpression(where) {
+		return where, true
+	}
+
+	dynamicWhere := &SQLDynamicWhereExpression{}
+
+	for _, c := range getAndConditionList(where) {
+		if optionalExpr, ok := c.(*SQLOptionalExpression); ok {
+			if hasOptionalExpression(optionalExpr.target) {
+				return nil, false
+			}
+
+			if binExpr, ok := optionalExpr.target.(*SQLBinaryExpression); ok && binExpr.op == "||" {
+				optionalExpr.target = &SQLParenthesisExpression{target: binExpr}
+			}
+		} else if hasOptionalExpression(c) {
+			return nil, false
+		}
+
+		dynamicWhere.conditions = append(dynamicWhere.conditions, c)
+	}
+
+	return dynamicWhere, true
+}
@@ -0,0 +1,257 @@
+package sqlcodegen
+
+import (
+	"fmt"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// Schema 数据库结构，由描述文件中的模型或已有的数据库生成
+type Schema struct {
+	Tables []*TableSchema
+}
+
+func (s *Schema) GetTable(name string) (*TableSchema, bool) {
+	for _, t := range s.Tables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+
+	return nil, false
+}
+
+type TableSchema struct {
+	Name       string
+	Columns    []*ColumnSchema
+	PrimaryKey []string
+	Indexes    []*IndexSchema
+}
+
+func (t *TableSchema) GetColumn(name string) (*ColumnSchema, bool) {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+
+	return nil, false
+}
+
+func (t *TableSchema) GetIndex(name string) (*IndexSchema, bool) {
+	for _, index := range t.Indexes {
+		if index.Name == name {
+			return index, true
+		}
+	}
+
+	return nil, false
+}
+
+type ColumnSchema struct {
+	Name     string
+	Type     string
+	Nullable bool
+	Identity bool
+	Unique   bool
+	Default  string
+
+	defaultName string
+}
+
+type IndexSchema struct {
+	Name    string
+	Columns []string
+	Unique  bool
+
+	isConstraint bool
+}
+
+// LoadSchema 读取描述文件中的模型，按照方言生成数据库结构
+func LoadSchema(dialect Dialect, srcFileNames ...string) (*Schema, error) {
+	d, err := getDDLDialect(dialect)
+
+	if err != nil {
+		return nil, err
+	}
+
+	context := &parseContext{}
+	context.entity = make(map[string]*table)
+	context.fset = token.NewFileSet()
+
+	if _, err := parseModelFiles(context, srcFileNames); err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{}
+
+	for _, t := range context.tables {
+		tableSchema, err := tableToSchema(context, t, d)
+
+		if err != nil {
+			return nil, err
+		}
+
+		schema.Tables = append(schema.Tables, tableSchema)
+	}
+
+	return schema, nil
+}
+
+func setColumnDDLTags(col *column, tags map[string]string) error {
+	col.isPrimaryKey = tags["pk"] == "true"
+	col.defaultValue = tags["default"]
+	col.columnType = tags["type"]
+
+	if unique, ok := tags["unique"]; ok && unique != "false" {
+		if unique == "true" {
+			col.isUnique = true
+		} else {
+			col.uniqueName = unique
+		}
+	}
+
+	if index, ok := tags["index"]; ok && index != "false" {
+		col.indexName = index
+	}
+
+	if size, ok := tags["size"]; ok {
+		n, err := strconv.Atoi(size)
+
+		if err != nil || n <= 0 {
+			return fmt.Errorf("error: invalid size %q", size)
+		}
+
+		col.size = n
+	}
+
+	return nil
+}
+
+func tableToSchema(context *parseContext, t *table, d ddlDialect) (*TableSchema, error) {
+	tableSchema := &TableSchema{Name: t.tableName}
+	var identityName string
+
+	for _, col := range t.columns {
+		columnSchema := &ColumnSchema{
+			Name:     col.columnName,
+			Type:     col.columnType,
+			Nullable: col.isNull,
+			Identity: col.isIdentity,
+			Unique:   col.isUnique,
+			Default:  col.defaultValue,
+		}
+
+		if columnSchema.Type == "" {
+			columnSchema.Type = d.columnType(getBaseType(col.sysType), col.size)
+
+			if columnSchema.Type == "" {
+				return nil, newTypeDefError(context, t.name, t.source)
+			}
+		}
+
+		if col.isPrimaryKey {
+			tableSchema.PrimaryKey = append(tableSchema.PrimaryKey, col.columnName)
+		}
+
+		if col.isIdentity && identityName == "" {
+			identityName = col.columnName
+		}
+
+		if col.indexName != "" {
+			name := col.indexName
+
+			if name == "true" {
+				name = getIndexName("idx", t.tableName, col.columnName)
+			}
+
+			addIndexColumn(tableSchema, name, col.columnName, false)
+		}
+
+		if col.uniqueName != "" {
+			addIndexColumn(tableSchema, col.uniqueName, col.columnName, true)
+		}
+
+		tableSchema.Columns = append(tableSchema.Columns, columnSchema)
+	}
+
+	if len(tableSchema.PrimaryKey) == 0 && identityName != "" {
+		tableSchema.PrimaryKey = []string{identityName}
+	}
+
+	// 自增列不是主键的第一列时，MySQL需要以自增列开头的索引
+	if d.identityNeedsKey() {
+		for _, c := range tableSchema.Columns {
+			if c.Identity && !hasLeadingKey(tableSchema, c) {
+				addIndexColumn(tableSchema, getIndexName("idx", t.tableName, c.Name), c.Name, false)
+			}
+		}
+	}
+
+	return tableSchema, nil
+}
+
+// hasLeadingKey 判断列是否为主键、唯一约束或某个索引的第一列
+func hasLeadingKey(t *TableSchema, c *ColumnSchema) bool {
+	if c.Unique || len(t.PrimaryKey) > 0 && t.PrimaryKey[0] == c.Name {
+		return true
+	}
+
+	for _, index := range t.Indexes {
+		if index.Columns[0] == c.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getIndexName(prefix string, tableName string, columnName string) string {
+	return prefix + "_" + strings.Replace(tableName, ".", "_", -1) + "_" + columnName
+}
+
+func addIndexColumn(tableSchema *TableSchema, name string, columnName string, unique bool) {
+	index, ok := tableSchema.GetIndex(name)
+
+	if !ok {
+		index = &IndexSchema{Name: name, Unique: unique}
+		tableSchema.Indexes = append(tableSchema.Indexes, index)
+	}
+
+	index.Columns = append(index.Columns, columnName)
+}
+
+// getBaseType 去掉指针与sql.Null包装，返回字段对应的基础类型
+func getBaseType(sysType string) string {
+	sysType = strings.TrimPrefix(sysType, "*")
+
+	if strings.HasPrefix(sysType, "sql.Null[") && strings.HasSuffix(sysType, "]") {
+		return getBaseType(sysType[len("sql.Null[") : len(sysType)-1])
+	}
+
+	switch sysType {
+	case "sql.NullString":
+		return "string"
+	case "sql.NullInt64":
+		return "int64"
+	case "sql.NullInt32":
+		return "int32"
+	case "sql.NullInt16":
+		return "int16"
+	case "sql.NullByte", "uint8":
+		return "byte"
+	case "sql.NullFloat64":
+		return "float64"
+	case "sql.NullBool":
+		return "bool"
+	case "sql.NullTime":
+		return "time.Time"
+	case "rune":
+		return "int32"
+	case "[]uint8":
+		return "[]byte"
+	}
+
+	return sysType
+}
@@ -0,0 +1,229 @@
+package sqlcodegen
+
+import (
+	"go/ast"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// sequenceStatement 多语句函数中的一条语句
+type sequenceStatement struct {
+	query    *sqlQuery
+	execCode string
+	// rangeParam 不为空时对切片参数中的每个元素执行一次
+	rangeParam string
+}
+
+// getStatementBlocks 返回多语句描述函数中的语句块，每个语句块描述一条语句，函数体中没有语句块时返回nil。
+// 返回的FuncDecl与funcDecl共用名称与参数，可以直接交给生成单条语句的函数处理
+func getStatementBlocks(funcDecl *ast.FuncDecl) []*ast.FuncDecl {
+	var result []*ast.FuncDecl
+
+	for _, stmt := range funcDecl.Body.List {
+		if block, ok := stmt.(*ast.BlockStmt); ok {
+			result = append(result, &ast.FuncDecl{Name: funcDecl.Name, Type: funcDecl.Type, Body: block})
+		}
+	}
+
+	return result
+}
+
+// getFunctionReturnsResult 判断描述函数生成的函数在error之前是否还有一个返回值：
+// 多语句函数返回sqlutil.Results，只调用其他描述函数的事务函数只返回error，其他函数返回查询或执行的结果
+func getFunctionReturnsResult(funcDecl *ast.FuncDecl) bool {
+	return getStatementBlocks(funcDecl) != nil || findSpecCall(funcDecl, "Transactional") == nil
+}
+
+// genSequenceFunction 生成按顺序执行各个语句块的函数，返回每条语句的结果sqlutil.Results，
+// 函数体中有Transactional()时所有语句在一个事务中执行
+func genSequenceFunction(context *parseContext, funcDecl *ast.FuncDecl, blocks []*ast.FuncDecl) error {
+	var transactional bool
+
+	for _, stmt := range funcDecl.Body.List {
+		switch inst := stmt.(type) {
+		case *ast.BlockStmt:
+			continue
+		case *ast.ExprStmt:
+			if callExpr, ok := inst.X.(*ast.CallExpr); ok {
+				if fun, ok := callExpr.Fun.(*ast.SelectorExpr); ok && fun.Sel.Name == "Transactional" {
+					if len(callExpr.Args) != 0 {
+						return newArgError(context, callExpr)
+					}
+
+					transactional = true
+					continue
+				}
+			}
+		}
+
+		return context.newError(stmt, "multi-statement function %s can only contain statement blocks and Transactional()", funcDecl.Name.Name)
+	}
+
+	dbName := "db"
+
+	if transactional {
+		dbName = "tx"
+	}
+
+	var statements []*sequenceStatement
+
+	for i, block := range blocks {
+		stmt, err := buildSequenceStatement(context, block, "query"+strconv.Itoa(i+1), dbName)
+
+		if err != nil {
+			return err
+		}
+
+		statements = append(statements, stmt)
+	}
+
+	generator := context.generator
+
+	funcResultList := []*ast.Field{newASTField(newASTRefExpr("sqlutil.Results"), "")}
+
+	genMethodBegin(context, funcDecl.Name.Name, funcDecl.Type.Params.List, funcResultList, funcDecl.Doc)
+
+	generator.writeLine("var results sqlutil.Results")
+
+	if transactional {
+		generator.write("err := sqlutil.WithTx(" + getContextArg(context) + ", db, " + getTxOptionsCode(context) + ", func(tx sqlutil.DbObject) error")
+		generator.beginBlock()
+		genSequenceStatements(context, statements, "err")
+		generator.writeLine("return nil")
+		generator.endBlock(")")
+		generator.write("if err != nil")
+		generator.beginBlock()
+		generator.writeLine("return nil, err")
+		generator.endBlock()
+	} else {
+		genSequenceStatements(context, statements, "nil, err")
+	}
+
+	generator.writeLine("return results, nil")
+
+	genMethodEnd(context)
+
+	return nil
+}
+
+func genSequenceStatements(context *parseContext, statements []*sequenceStatement, errReturn string) {
+	generator := context.generator
+
+	for _, stmt := range statements {
+		genQueryDeclaration(context, stmt.query)
+
+		if stmt.rangeParam != "" {
+			generator.write("for _, item := range " + stmt.rangeParam)
+			generator.beginBlock()
+		}
+
+		generator.write("if err := results.Add(" + stmt.execCode + "); err != nil")
+		generator.beginBlock()
+		generator.writeLine("return ", errReturn)
+		generator.endBlock()
+
+		if stmt.rangeParam != "" {
+			generator.endBlock()
+		}
+	}
+}
+
+// buildSequenceStatement 返回语句块描述的语句，语句块中只能使用INSERT、UPDATE、DELETE语句
+func buildSequenceStatement(context *parseContext, block *ast.FuncDecl, name string, dbName string) (*sequenceStatement, error) {
+	for callExpr := range getCallExprList(block) {
+		methodName := callExpr.Fun.(*ast.SelectorExpr).Sel.Name
+
+		switch {
+		case strings.HasPrefix(methodName, "Insert"):
+			return buildSequenceInsert(context, block, callExpr, name, dbName)
+		case strings.HasPrefix(methodName, "Update"):
+			query, err := buildUpdateQuery(context, block, name)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return &sequenceStatement{query: query, execCode: getExecCode(context, query, dbName)}, nil
+		case strings.HasPrefix(methodName, "Delete"):
+			query, err := buildDeleteQuery(context, block, name)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return &sequenceStatement{query: query, execCode: getExecCode(context, query, dbName)}, nil
+		case strings.HasPrefix(methodName, "Select"), methodName == "ExecProcedure", methodName == "Transactional":
+			return nil, context.newError(callExpr, "%s cannot be used in a statement block, only INSERT, UPDATE and DELETE are supported",
+				types.ExprString(callExpr.Fun))
+		}
+	}
+
+	return nil, context.newError(block.Body, "statement block does not describe an INSERT, UPDATE or DELETE statement")
+}
+
+// buildSequenceInsert 函数中有类型为*T的参数时插入该参数的字段，参数类型为[]*T时逐个插入切片中的元素；
+// 没有这样的参数时Insert(columns...)插入同名的函数参数
+func buildSequenceInsert(context *parseContext, block *ast.FuncDecl, insertCall *ast.CallExpr, name string, dbName string) (*sequenceStatement, error) {
+	entity, _, err := getInsertColumns(context, insertCall)
+
+	if err != nil {
+		return nil, err
+	}
+
+	paramName, isSlice, err := getEntityParam(context, block, entity, insertCall)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if paramName == "" && insertCall.Fun.(*ast.SelectorExpr).Sel.Name == "InsertAll" {
+		return nil, context.newError(insertCall, "%s needs a parameter of type *%s or []*%s",
+			types.ExprString(insertCall), entity.name, entity.name)
+	}
+
+	stmt := &sequenceStatement{}
+	objName := paramName
+
+	if isSlice {
+		stmt.rangeParam = paramName
+		objName = "item"
+	}
+
+	insertQuery, err := buildInsertQuery(context, block, insertCall, name, objName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.query = insertQuery.query
+	stmt.execCode = getInsertExecCode(context, insertQuery, dbName)
+
+	return stmt, nil
+}
+
+// getEntityParam 返回函数中类型为*T或[]*T的参数，T为entity的模型，有多个这样的参数时在call处报告错误
+func getEntityParam(context *parseContext, funcDecl *ast.FuncDecl, entity *table, call *ast.CallExpr) (paramName string, isSlice bool, err error) {
+	for _, field := range funcDecl.Type.Params.List {
+		var fieldIsSlice bool
+
+		switch types.ExprString(field.Type) {
+		case "*" + entity.name:
+		case "[]*" + entity.name:
+			fieldIsSlice = true
+		default:
+			continue
+		}
+
+		for _, ident := range field.Names {
+			if paramName != "" {
+				return "", false, context.newError(call, "%s is ambiguous, both %s and %s are %s parameters",
+					types.ExprString(call), paramName, ident.Name, entity.name)
+			}
+
+			paramName, isSlice = ident.Name, fieldIsSlice
+		}
+	}
+
+	return paramName, isSlice, nil
+}
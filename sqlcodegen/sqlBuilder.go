@@ -10,7 +10,27 @@ type SQLExpression interface {
 }
 
 type SQLParameterExpression struct {
-	name string
+	name   string
+	isList bool
+	isOut  bool
+	// compareOp 不为空时参数与比较运算符一起在运行时写入，参数为nil时生成 IS NULL
+	compareOp string
+}
+
+func (expr *SQLParameterExpression) getArgCode() string {
+	if expr.compareOp != "" {
+		return "sqlutil.Compare(" + strconv.Quote(expr.compareOp) + ", " + expr.name + ")"
+	}
+
+	if expr.isList {
+		return "sqlutil.List(" + expr.name + ")"
+	}
+
+	if expr.isOut {
+		return "sql.Out{Dest: " + expr.name + "}"
+	}
+
+	return expr.name
 }
 
 type SQLBinaryExpression struct {
@@ -24,7 +44,7 @@ type SQLLiteralExpression struct {
 }
 
 type SQLOrderExpression struct {
-	column       *SQLColumnExpression
+	column       SQLExpression
 	isDescending bool
 }
 
@@ -32,19 +52,112 @@ type SQLParenthesisExpression struct {
 	target SQLExpression
 }
 
+type SQLListExpression struct {
+	items []SQLExpression
+}
+
+type SQLInExpression struct {
+	column SQLExpression
+	values SQLExpression
+	not    bool
+}
+
+type SQLLikeExpression struct {
+	column  SQLExpression
+	pattern SQLExpression
+	not     bool
+}
+
+type SQLBetweenExpression struct {
+	column SQLExpression
+	from   SQLExpression
+	to     SQLExpression
+}
+
+type SQLIsNullExpression struct {
+	column SQLExpression
+	not    bool
+}
+
+type SQLNullableCompareExpression struct {
+	column SQLExpression
+	param  *SQLParameterExpression
+	not    bool
+}
+
+type SQLOptionalExpression struct {
+	target SQLExpression
+}
+
+type SQLDynamicWhereExpression struct {
+	conditions []SQLExpression
+}
+
 type SQLColumnExpression struct {
 	tableName  string
+	entityName string
 	columnName string
 
 	source *column
 }
 
+type SQLAggregateExpression struct {
+	function string
+	column   *SQLColumnExpression
+}
+
+func (expr *SQLAggregateExpression) getResultType() string {
+	if expr.function == "Count" {
+		return "int64"
+	}
+
+	if expr.function == "Avg" {
+		return "sql.NullFloat64"
+	}
+
+	return getNullType(expr.column.source.sysType)
+}
+
+func getNullType(sysType string) string {
+	if strings.HasPrefix(sysType, "sql.Null") {
+		return sysType
+	}
+
+	switch sysType {
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64", "byte", "rune":
+		return "sql.NullInt64"
+	case "float32", "float64":
+		return "sql.NullFloat64"
+	case "string":
+		return "sql.NullString"
+	case "bool":
+		return "sql.NullBool"
+	case "time.Time":
+		return "sql.NullTime"
+	}
+
+	return sysType
+}
+
+type SQLJoinExpression struct {
+	joinType  string
+	table     string
+	alias     string
+	condition SQLExpression
+}
+
 type SQLSelectStatement struct {
 	selectList  []SQLExpression
 	table       string
+	alias       string
+	joinList    []*SQLJoinExpression
 	where       SQLExpression
+	groupByList []SQLExpression
+	having      SQLExpression
 	orderByList []*SQLOrderExpression
-	limitRows   int
+	limit       SQLExpression
+	offset      SQLExpression
 }
 
 func (stmt *SQLSelectStatement) getFirstColumnExpression() (*SQLColumnExpression, bool) {
@@ -58,7 +171,10 @@ func (stmt *SQLSelectStatement) getFirstColumnExpression() (*SQLColumnExpression
 
 type SQLInsertStatement struct {
 	columns []string
+	values  []SQLExpression
 	table   string
+	// returning 插入后返回的自增列，只用于RETURNING、OUTPUT方式的方言
+	returning string
 }
 
 type SQLUpdateStatement struct {
@@ -72,6 +188,11 @@ type SQLDeleteStatement struct {
 	where SQLExpression
 }
 
+type SQLProcedureStatement struct {
+	name string
+	args []SQLExpression
+}
+
 type SQLBuilder interface {
 	Reset()
 	String() string
@@ -87,16 +208,49 @@ type SQLBuilder interface {
 }
 
 type defaultSQLBuilder struct {
-	buffer bytes.Buffer
+	buffer         bytes.Buffer
+	dialect        sqlDialect
+	paramList      []*SQLParameterExpression
+	qualifyColumns bool
+	dynamic        bool
+	dynamicSQL     *dynamicSQL
+	dynamicWhere   int
 }
 
 func newDefaultSQLBuilder() *defaultSQLBuilder {
-	b := &defaultSQLBuilder{}
+	return newSQLBuilder(defaultDialect{})
+}
+
+func newSQLBuilder(dialect sqlDialect) *defaultSQLBuilder {
+	b := &defaultSQLBuilder{dialect: dialect}
 	return b
 }
 
 func (builder *defaultSQLBuilder) Reset() {
 	builder.buffer.Reset()
+	builder.paramList = nil
+	builder.dynamicSQL = nil
+}
+
+func (builder *defaultSQLBuilder) setDynamic(dynamic bool) {
+	builder.dynamic = dynamic
+}
+
+func (builder *defaultSQLBuilder) getBindType() string {
+	return builder.dialect.bindType()
+}
+
+func (builder *defaultSQLBuilder) getDynamicSQL() *dynamicSQL {
+	if builder.dynamicSQL == nil {
+		return &dynamicSQL{prefix: builder.String(), prefixParams: builder.paramList}
+	}
+
+	d := *builder.dynamicSQL
+	d.prefix = builder.String()[:builder.dynamicWhere]
+	d.suffix = builder.String()[builder.dynamicWhere:]
+	d.suffixParams = builder.paramList[len(d.prefixParams):]
+
+	return &d
 }
 
 func (builder *defaultSQLBuilder) String() string {
@@ -108,7 +262,11 @@ func (builder *defaultSQLBuilder) Write(code string) {
 }
 
 func (builder *defaultSQLBuilder) WriteLine() {
-	builder.Write(`\n`)
+	builder.Write("\n")
+}
+
+func (builder *defaultSQLBuilder) writeIdentifier(name string) {
+	builder.Write(quoteName(builder.dialect, name))
 }
 
 func (builder *defaultSQLBuilder) WriteWhere(where SQLExpression) {
@@ -116,21 +274,53 @@ func (builder *defaultSQLBuilder) WriteWhere(where SQLExpression) {
 		return
 	}
 
+	if dynamicWhere, ok := where.(*SQLDynamicWhereExpression); ok && builder.dynamic {
+		builder.writeDynamicWhere(dynamicWhere)
+		return
+	}
+
 	builder.Write("WHERE ")
 	builder.WriteSQLExpression(where)
 	builder.WriteLine()
 }
 
+func (builder *defaultSQLBuilder) writeDynamicWhere(where *SQLDynamicWhereExpression) {
+	d := &dynamicSQL{}
+	d.prefixParams = builder.paramList
+	builder.dynamicWhere = builder.buffer.Len()
+
+	for _, c := range where.conditions {
+		condition := &dynamicCondition{}
+		paramIndex := len(builder.paramList)
+
+		if optionalExpr, ok := c.(*SQLOptionalExpression); ok {
+			condition.isOptional = true
+			c = optionalExpr.target
+		}
+
+		builder.WriteSQLExpression(c)
+
+		condition.text = builder.String()[builder.dynamicWhere:]
+		condition.paramList = builder.paramList[paramIndex:]
+		d.conditions = append(d.conditions, condition)
+
+		builder.buffer.Truncate(builder.dynamicWhere)
+		builder.paramList = builder.paramList[:paramIndex:paramIndex]
+	}
+
+	builder.dynamicSQL = d
+}
+
 func (builder *defaultSQLBuilder) WriteDeleteStatement(stmt *SQLDeleteStatement) {
-	builder.Write("DELETE ")
-	builder.Write(stmt.table)
+	builder.Write(builder.dialect.deleteKeyword())
+	builder.writeIdentifier(stmt.table)
 	builder.WriteLine()
 	builder.WriteWhere(stmt.where)
 }
 
 func (builder *defaultSQLBuilder) WriteUpdateStatement(stmt *SQLUpdateStatement) {
 	builder.Write("UPDATE ")
-	builder.Write(stmt.table)
+	builder.writeIdentifier(stmt.table)
 	builder.WriteLine()
 	builder.Write("SET ")
 
@@ -151,7 +341,7 @@ func (builder *defaultSQLBuilder) WriteUpdateStatement(stmt *SQLUpdateStatement)
 
 func (builder *defaultSQLBuilder) WriteInsertStatement(stmt *SQLInsertStatement) {
 	builder.Write("INSERT INTO ")
-	builder.Write(stmt.table)
+	builder.writeIdentifier(stmt.table)
 	builder.Write("(")
 
 	for i, col := range stmt.columns {
@@ -159,27 +349,98 @@ func (builder *defaultSQLBuilder) WriteInsertStatement(stmt *SQLInsertStatement)
 			builder.Write(",")
 		}
 
-		builder.Write(col)
+		builder.writeIdentifier(col)
 	}
 
 	builder.Write(")")
 	builder.WriteLine()
+
+	mode := builder.dialect.identityMode()
+
+	if stmt.returning != "" && mode == identityOutput {
+		builder.Write("OUTPUT INSERTED.")
+		builder.writeIdentifier(stmt.returning)
+		builder.WriteLine()
+	}
+
 	builder.Write("VALUES(")
 
-	for i := range stmt.columns {
+	for i, expr := range stmt.values {
 		if i > 0 {
 			builder.Write(",")
 		}
 
-		builder.Write("?")
+		builder.WriteSQLExpression(expr)
 	}
 
 	builder.Write(")")
+
+	if stmt.returning != "" && mode == identityReturning {
+		builder.WriteLine()
+		builder.Write("RETURNING ")
+		builder.writeIdentifier(stmt.returning)
+	}
+}
+
+func (builder *defaultSQLBuilder) identityMode() identityMode {
+	return builder.dialect.identityMode()
+}
+
+func (builder *defaultSQLBuilder) supportsOutParameter() bool {
+	_, ok := builder.dialect.outParameter()
+	return ok
+}
+
+func (builder *defaultSQLBuilder) writeProcedureStatement(stmt *SQLProcedureStatement) bool {
+	keyword, withParen := builder.dialect.procedureKeyword()
+
+	if keyword == "" {
+		return false
+	}
+
+	builder.Write(keyword)
+	builder.writeIdentifier(stmt.name)
+
+	if withParen {
+		builder.Write("(")
+	} else if len(stmt.args) > 0 {
+		builder.Write(" ")
+	}
+
+	for i, arg := range stmt.args {
+		if i > 0 {
+			builder.Write(", ")
+		}
+
+		builder.WriteSQLExpression(arg)
+
+		if p, ok := arg.(*SQLParameterExpression); ok && p.isOut {
+			keyword, _ := builder.dialect.outParameter()
+			builder.Write(keyword)
+		}
+	}
+
+	if withParen {
+		builder.Write(")")
+	}
+
+	return true
 }
 
 func (builder *defaultSQLBuilder) WriteSelectStatement(stmt *SQLSelectStatement) {
+	builder.qualifyColumns = len(stmt.joinList) > 0
+	defer func() { builder.qualifyColumns = false }()
+
 	builder.Write("SELECT ")
 
+	useTop := builder.dialect.useTop()
+
+	if useTop && stmt.limit != nil && stmt.offset == nil {
+		builder.Write("TOP (")
+		builder.WriteSQLExpression(stmt.limit)
+		builder.Write(") ")
+	}
+
 	for i, expr := range stmt.selectList {
 		if i > 0 {
 			builder.Write(", ")
@@ -192,12 +453,43 @@ func (builder *defaultSQLBuilder) WriteSelectStatement(stmt *SQLSelectStatement)
 
 	if stmt.table != "" {
 		builder.Write("FROM ")
-		builder.Write(stmt.table)
+		builder.writeIdentifier(stmt.table)
+
+		if builder.qualifyColumns && stmt.alias != "" {
+			builder.Write(" ")
+			builder.writeIdentifier(stmt.alias)
+		}
+
+		builder.WriteLine()
+	}
+
+	for _, join := range stmt.joinList {
+		builder.WriteSQLExpression(join)
 		builder.WriteLine()
 	}
 
 	builder.WriteWhere(stmt.where)
 
+	if len(stmt.groupByList) > 0 {
+		builder.Write("GROUP BY ")
+
+		for i, expr := range stmt.groupByList {
+			if i > 0 {
+				builder.Write(",")
+			}
+
+			builder.WriteSQLExpression(expr)
+		}
+
+		builder.WriteLine()
+	}
+
+	if stmt.having != nil {
+		builder.Write("HAVING ")
+		builder.WriteSQLExpression(stmt.having)
+		builder.WriteLine()
+	}
+
 	if stmt.orderByList != nil && len(stmt.orderByList) > 0 {
 		builder.Write("ORDER BY ")
 
@@ -210,20 +502,69 @@ func (builder *defaultSQLBuilder) WriteSelectStatement(stmt *SQLSelectStatement)
 		}
 
 		builder.WriteLine()
+	} else if useTop && stmt.offset != nil {
+		builder.Write("ORDER BY (SELECT NULL)")
+		builder.WriteLine()
+	}
+
+	if useTop {
+		if stmt.offset != nil {
+			builder.Write("OFFSET ")
+			builder.WriteSQLExpression(stmt.offset)
+			builder.Write(" ROWS")
+			builder.WriteLine()
+
+			if stmt.limit != nil {
+				builder.Write("FETCH NEXT ")
+				builder.WriteSQLExpression(stmt.limit)
+				builder.Write(" ROWS ONLY")
+				builder.WriteLine()
+			}
+		}
+
+		return
 	}
 
-	if stmt.limitRows > 0 {
+	if stmt.limit != nil {
 		builder.Write("LIMIT ")
-		builder.Write(strconv.Itoa(stmt.limitRows))
+		builder.WriteSQLExpression(stmt.limit)
+		builder.WriteLine()
+	} else if stmt.offset != nil && builder.dialect.maxLimit() != "" {
+		builder.Write("LIMIT ")
+		builder.Write(builder.dialect.maxLimit())
+		builder.WriteLine()
+	}
+
+	if stmt.offset != nil {
+		builder.Write("OFFSET ")
+		builder.WriteSQLExpression(stmt.offset)
 		builder.WriteLine()
 	}
 }
 
+func writeCountStatement(builder SQLBuilder, stmt *SQLSelectStatement) {
+	countStmt := *stmt
+	countStmt.orderByList = nil
+	countStmt.limit = nil
+	countStmt.offset = nil
+
+	if len(stmt.groupByList) > 0 {
+		builder.Write("SELECT COUNT(*) FROM (")
+		builder.WriteLine()
+		builder.WriteSelectStatement(&countStmt)
+		builder.Write(") t")
+		builder.WriteLine()
+		return
+	}
+
+	countStmt.selectList = []SQLExpression{&SQLAggregateExpression{function: "Count"}}
+	builder.WriteSelectStatement(&countStmt)
+}
+
 func (builder *defaultSQLBuilder) WriteSQLExpression(expr SQLExpression) {
 	switch inst := expr.(type) {
 	case *SQLLiteralExpression:
-		v := strings.Replace(inst.value, `"`, `\"`, -1)
-		builder.Write(v)
+		builder.Write(toSQLLiteral(inst.value))
 
 	case *SQLParenthesisExpression:
 		builder.Write("(")
@@ -231,14 +572,116 @@ func (builder *defaultSQLBuilder) WriteSQLExpression(expr SQLExpression) {
 		builder.Write(")")
 
 	case *SQLColumnExpression:
-		/*if inst.tableName != "" {
-			builder.Write(inst.tableName)
+		if builder.qualifyColumns && inst.entityName != "" {
+			builder.writeIdentifier(inst.entityName)
 			builder.Write(".")
-		}*/
-		builder.Write(inst.columnName)
+		}
+		builder.writeIdentifier(inst.columnName)
+
+	case *SQLAggregateExpression:
+		builder.Write(strings.ToUpper(inst.function))
+		builder.Write("(")
+
+		if inst.column == nil {
+			builder.Write("*")
+		} else {
+			builder.WriteSQLExpression(inst.column)
+		}
+
+		builder.Write(")")
+
+	case *SQLJoinExpression:
+		builder.Write(inst.joinType)
+		builder.Write(" ")
+		builder.writeIdentifier(inst.table)
+		builder.Write(" ")
+		builder.writeIdentifier(inst.alias)
+		builder.Write(" ON ")
+		builder.WriteSQLExpression(inst.condition)
 
 	case *SQLParameterExpression:
-		builder.Write("?")
+		builder.paramList = append(builder.paramList, inst)
+
+		if builder.dynamic {
+			builder.Write("?")
+		} else {
+			builder.Write(builder.dialect.placeholder(len(builder.paramList)))
+		}
+
+	case *SQLListExpression:
+		for i, item := range inst.items {
+			if i > 0 {
+				builder.Write(", ")
+			}
+
+			builder.WriteSQLExpression(item)
+		}
+
+	case *SQLInExpression:
+		builder.WriteSQLExpression(inst.column)
+
+		if inst.not {
+			builder.Write(" NOT")
+		}
+
+		builder.Write(" IN (")
+		builder.WriteSQLExpression(inst.values)
+		builder.Write(")")
+
+	case *SQLLikeExpression:
+		builder.WriteSQLExpression(inst.column)
+
+		if inst.not {
+			builder.Write(" NOT")
+		}
+
+		builder.Write(" LIKE ")
+		builder.WriteSQLExpression(inst.pattern)
+
+	case *SQLBetweenExpression:
+		builder.WriteSQLExpression(inst.column)
+		builder.Write(" BETWEEN ")
+		builder.WriteSQLExpression(inst.from)
+		builder.Write(" AND ")
+		builder.WriteSQLExpression(inst.to)
+
+	case *SQLIsNullExpression:
+		builder.WriteSQLExpression(inst.column)
+
+		if inst.not {
+			builder.Write(" IS NOT NULL")
+		} else {
+			builder.Write(" IS NULL")
+		}
+
+	case *SQLNullableCompareExpression:
+		builder.WriteSQLExpression(inst.column)
+
+		op := "="
+
+		if inst.not {
+			op = "<>"
+		}
+
+		if builder.dynamic {
+			builder.paramList = append(builder.paramList, &SQLParameterExpression{name: inst.param.name, compareOp: op})
+			builder.Write(" ?")
+		} else {
+			builder.Write(" " + op + " ")
+			builder.WriteSQLExpression(inst.param)
+		}
+
+	case *SQLOptionalExpression:
+		builder.WriteSQLExpression(inst.target)
+
+	case *SQLDynamicWhereExpression:
+		for i, c := range inst.conditions {
+			if i > 0 {
+				builder.Write(" AND ")
+			}
+
+			builder.WriteSQLExpression(c)
+		}
 
 	case *SQLBinaryExpression:
 		builder.WriteSQLExpression(inst.left)
@@ -269,7 +712,26 @@ func (builder *defaultSQLBuilder) WriteSQLExpression(expr SQLExpression) {
 }
 
 func (builder *defaultSQLBuilder) GetInvokeParameterList(paramList []*SQLParameterExpression) []*SQLParameterExpression {
-	return paramList
+	if len(builder.paramList) != len(paramList) {
+		return paramList
+	}
+
+	return builder.paramList
+}
+
+func toSQLLiteral(value string) string {
+	if !strings.HasPrefix(value, "`") &&
+		!strings.HasPrefix(value, `"`) {
+		return value
+	}
+
+	s, err := strconv.Unquote(value)
+
+	if err != nil {
+		return value
+	}
+
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
 }
 
 func getSqlParamListFromExpression(expr SQLExpression) []*SQLParameterExpression {
@@ -280,6 +742,35 @@ func getSqlParamListFromExpression(expr SQLExpression) []*SQLParameterExpression
 		list = append(list, getSqlParamListFromExpression(inst.left)...)
 		list = append(list, getSqlParamListFromExpression(inst.right)...)
 
+	case *SQLParenthesisExpression:
+		list = append(list, getSqlParamListFromExpression(inst.target)...)
+
+	case *SQLListExpression:
+		for _, item := range inst.items {
+			list = append(list, getSqlParamListFromExpression(item)...)
+		}
+
+	case *SQLInExpression:
+		list = append(list, getSqlParamListFromExpression(inst.values)...)
+
+	case *SQLLikeExpression:
+		list = append(list, getSqlParamListFromExpression(inst.pattern)...)
+
+	case *SQLBetweenExpression:
+		list = append(list, getSqlParamListFromExpression(inst.from)...)
+		list = append(list, getSqlParamListFromExpression(inst.to)...)
+
+	case *SQLNullableCompareExpression:
+		list = append(list, inst.param)
+
+	case *SQLOptionalExpression:
+		list = append(list, getSqlParamListFromExpression(inst.target)...)
+
+	case *SQLDynamicWhereExpression:
+		for _, c := range inst.conditions {
+			list = append(list, getSqlParamListFromExpression(c)...)
+		}
+
 	case *SQLParameterExpression:
 		list = append(list, inst)
 	}
@@ -290,7 +781,14 @@ func getSqlParamListFromExpression(expr SQLExpression) []*SQLParameterExpression
 func getSelectStmtSqlParamList(stmt *SQLSelectStatement) []*SQLParameterExpression {
 	list := make([]*SQLParameterExpression, 0)
 
+	for _, join := range stmt.joinList {
+		list = append(list, getSqlParamListFromExpression(join.condition)...)
+	}
+
 	list = append(list, getSqlParamListFromExpression(stmt.where)...)
+	list = append(list, getSqlParamListFromExpression(stmt.having)...)
+	list = append(list, getSqlParamListFromExpression(stmt.limit)...)
+	list = append(list, getSqlParamListFromExpression(stmt.offset)...)
 
 	return list
 }
@@ -309,43 +807,47 @@ func getUpdateStmtSqlParamList(stmt *SQLUpdateStatement) []*SQLParameterExpressi
 	return list
 }
 
+func getInsertStmtSqlParamList(stmt *SQLInsertStatement) []*SQLParameterExpression {
+	list := make([]*SQLParameterExpression, 0)
+
+	for _, expr := range stmt.values {
+		list = append(list, getSqlParamListFromExpression(expr)...)
+	}
+
+	return list
+}
+
+func getProcedureStmtSqlParamList(stmt *SQLProcedureStatement) []*SQLParameterExpression {
+	list := make([]*SQLParameterExpression, 0)
+
+	for _, arg := range stmt.args {
+		list = append(list, getSqlParamListFromExpression(arg)...)
+	}
+
+	return list
+}
+
 func getDeleteStmtSqlParamList(stmt *SQLDeleteStatement) []*SQLParameterExpression {
 	return getSqlParamListFromExpression(stmt.where)
 }
 
-func tableToSelectStatement(builder SQLBuilder, stmt *SQLSelectStatement, table *table) *SQLSelectStatement {
+func tableToSelectStatement(builder SQLBuilder, stmt *SQLSelectStatement, table *table, entityName string) *SQLSelectStatement {
 	if stmt == nil {
 		stmt = &SQLSelectStatement{}
 	}
 
 	stmt.table = table.tableName
+	stmt.alias = entityName
 
 	for _, col := range table.columns {
 		colExpr := &SQLColumnExpression{}
 		colExpr.columnName = col.columnName
 		colExpr.source = col
 		colExpr.tableName = stmt.table
+		colExpr.entityName = entityName
 
 		stmt.selectList = append(stmt.selectList, colExpr)
 	}
 
 	return stmt
 }
-
-func tableToInsertStatement(builder SQLBuilder, stmt *SQLInsertStatement, table *table) *SQLInsertStatement {
-	if stmt == nil {
-		stmt = &SQLInsertStatement{}
-	}
-
-	stmt.table = table.tableName
-
-	for _, col := range table.columns {
-		if col.isIdentity {
-			continue
-		}
-
-		stmt.columns = append(stmt.columns, col.columnName)
-	}
-
-	return stmt
-}
@@ -0,0 +1,43 @@
+package aggregate
+
+import "github.com/YiCodes/gosql/sqlcodegen"
+
+type User struct {
+	UserID string
+	Sex    byte
+}
+
+type Order struct {
+	OrderID int64
+	UserID  string
+	Amount  float64
+	Items   int32
+}
+
+var (
+	user  User
+	order Order
+)
+
+func CountUsers(sex byte) {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(sqlcodegen.Count())
+	sqlcodegen.Where(user.Sex == sex)
+	sqlcodegen.SetReturnType(sqlcodegen.ReturnScalar)
+}
+
+func GetOrderStats(minCount int64) {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserID, sqlcodegen.Count(), sqlcodegen.Sum(order.Amount))
+	sqlcodegen.InnerJoin(order, order.UserID == user.UserID)
+	sqlcodegen.GroupBy(user.UserID)
+	sqlcodegen.Having(sqlcodegen.Count() > minCount)
+}
+
+func GetItemStats() {
+	sqlcodegen.From(order)
+	sqlcodegen.Select(order.UserID, sqlcodegen.Count(order.OrderID), sqlcodegen.Min(order.Items), sqlcodegen.Max(order.Amount), sqlcodegen.Avg(order.Items))
+	sqlcodegen.GroupBy(order.UserID)
+	sqlcodegen.Having(sqlcodegen.Sum(order.Amount) >= 100 && sqlcodegen.Max(order.Items) < 10)
+	sqlcodegen.OrderByDescending(order.UserID)
+}
@@ -0,0 +1,33 @@
+package ddl
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/YiCodes/gosql/sqlcodegen"
+)
+
+type User struct {
+	sqlcodegen.TableName `tableName:"users"`
+	UserID               int64  `identity:"true"`
+	UserName             string `size:"50" unique:"true"`
+	Email                sql.NullString
+	Sex                  byte `default:"0" index:"true"`
+	Created              time.Time
+	Avatar               []byte
+}
+
+type OrderItem struct {
+	OrderID   int64  `pk:"true"`
+	ProductID string `pk:"true" size:"20" index:"ix_product"`
+	Quantity  int32  `default:"1"`
+	Price     float64
+	Paid      bool
+}
+
+// Payment 自增列不是主键，MySQL中需要以自增列开头的索引
+type Payment struct {
+	PaymentNo string `pk:"true" size:"32"`
+	Seq       int64  `identity:"true"`
+	Amount    float64
+}
@@ -0,0 +1,28 @@
+CREATE TABLE `users` (
+    `UserID` BIGINT NOT NULL AUTO_INCREMENT,
+    `UserName` VARCHAR(50) NOT NULL UNIQUE,
+    `Email` VARCHAR(255) NULL,
+    `Sex` TINYINT UNSIGNED NOT NULL DEFAULT 0,
+    `Created` DATETIME NOT NULL,
+    `Avatar` BLOB NOT NULL,
+    PRIMARY KEY (`UserID`)
+);
+CREATE INDEX `idx_users_Sex` ON `users` (`Sex`);
+
+CREATE TABLE `OrderItem` (
+    `OrderID` BIGINT NOT NULL,
+    `ProductID` VARCHAR(20) NOT NULL,
+    `Quantity` INT NOT NULL DEFAULT 1,
+    `Price` DOUBLE NOT NULL,
+    `Paid` TINYINT(1) NOT NULL,
+    PRIMARY KEY (`OrderID`, `ProductID`)
+);
+CREATE INDEX `ix_product` ON `OrderItem` (`ProductID`);
+
+CREATE TABLE `Payment` (
+    `PaymentNo` VARCHAR(32) NOT NULL,
+    `Seq` BIGINT NOT NULL AUTO_INCREMENT,
+    `Amount` DOUBLE NOT NULL,
+    PRIMARY KEY (`PaymentNo`),
+    KEY `idx_Payment_Seq` (`Seq`)
+);
@@ -0,0 +1,27 @@
+CREATE TABLE "users" (
+    "UserID" BIGINT NOT NULL GENERATED BY DEFAULT AS IDENTITY,
+    "UserName" VARCHAR(50) NOT NULL UNIQUE,
+    "Email" TEXT NULL,
+    "Sex" SMALLINT NOT NULL DEFAULT 0,
+    "Created" TIMESTAMP NOT NULL,
+    "Avatar" BYTEA NOT NULL,
+    PRIMARY KEY ("UserID")
+);
+CREATE INDEX "idx_users_Sex" ON "users" ("Sex");
+
+CREATE TABLE "OrderItem" (
+    "OrderID" BIGINT NOT NULL,
+    "ProductID" VARCHAR(20) NOT NULL,
+    "Quantity" INTEGER NOT NULL DEFAULT 1,
+    "Price" DOUBLE PRECISION NOT NULL,
+    "Paid" BOOLEAN NOT NULL,
+    PRIMARY KEY ("OrderID", "ProductID")
+);
+CREATE INDEX "ix_product" ON "OrderItem" ("ProductID");
+
+CREATE TABLE "Payment" (
+    "PaymentNo" VARCHAR(32) NOT NULL,
+    "Seq" BIGINT NOT NULL GENERATED BY DEFAULT AS IDENTITY,
+    "Amount" DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY ("PaymentNo")
+);
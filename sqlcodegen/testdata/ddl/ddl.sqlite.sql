@@ -0,0 +1,26 @@
+CREATE TABLE "users" (
+    "UserID" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "UserName" TEXT NOT NULL UNIQUE,
+    "Email" TEXT NULL,
+    "Sex" INTEGER NOT NULL DEFAULT 0,
+    "Created" DATETIME NOT NULL,
+    "Avatar" BLOB NOT NULL
+);
+CREATE INDEX "idx_users_Sex" ON "users" ("Sex");
+
+CREATE TABLE "OrderItem" (
+    "OrderID" INTEGER NOT NULL,
+    "ProductID" TEXT NOT NULL,
+    "Quantity" INTEGER NOT NULL DEFAULT 1,
+    "Price" REAL NOT NULL,
+    "Paid" INTEGER NOT NULL,
+    PRIMARY KEY ("OrderID", "ProductID")
+);
+CREATE INDEX "ix_product" ON "OrderItem" ("ProductID");
+
+CREATE TABLE "Payment" (
+    "PaymentNo" TEXT NOT NULL,
+    "Seq" INTEGER NOT NULL,
+    "Amount" REAL NOT NULL,
+    PRIMARY KEY ("PaymentNo")
+);
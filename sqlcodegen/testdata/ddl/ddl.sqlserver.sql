@@ -0,0 +1,27 @@
+CREATE TABLE [users] (
+    [UserID] BIGINT NOT NULL IDENTITY(1,1),
+    [UserName] NVARCHAR(50) NOT NULL UNIQUE,
+    [Email] NVARCHAR(MAX) NULL,
+    [Sex] TINYINT NOT NULL DEFAULT 0,
+    [Created] DATETIME2 NOT NULL,
+    [Avatar] VARBINARY(MAX) NOT NULL,
+    PRIMARY KEY ([UserID])
+);
+CREATE INDEX [idx_users_Sex] ON [users] ([Sex]);
+
+CREATE TABLE [OrderItem] (
+    [OrderID] BIGINT NOT NULL,
+    [ProductID] NVARCHAR(20) NOT NULL,
+    [Quantity] INT NOT NULL DEFAULT 1,
+    [Price] FLOAT NOT NULL,
+    [Paid] BIT NOT NULL,
+    PRIMARY KEY ([OrderID], [ProductID])
+);
+CREATE INDEX [ix_product] ON [OrderItem] ([ProductID]);
+
+CREATE TABLE [Payment] (
+    [PaymentNo] NVARCHAR(32) NOT NULL,
+    [Seq] BIGINT NOT NULL IDENTITY(1,1),
+    [Amount] FLOAT NOT NULL,
+    PRIMARY KEY ([PaymentNo])
+);
@@ -0,0 +1,37 @@
+package dialect
+
+import "github.com/YiCodes/gosql/sqlcodegen"
+
+type User struct {
+	sqlcodegen.TableName `tableName:"users"`
+	UserID               int64 `identity:"true"`
+	UserName             string
+}
+
+var user User
+
+func GetUsers(userName string, skip int, take int) {
+	sqlcodegen.From(user)
+	sqlcodegen.SelectAll(user)
+	sqlcodegen.Where(user.UserName == userName)
+	sqlcodegen.OrderBy(user.UserID)
+	sqlcodegen.Limit(take)
+	sqlcodegen.Offset(skip)
+}
+
+func GetFirstUsers(take int) {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserName)
+	sqlcodegen.OrderBy(user.UserID)
+	sqlcodegen.Limit(take)
+}
+
+func AddUser() {
+	sqlcodegen.InsertAll(user)
+}
+
+func RenameUser(userID int64, userName string) {
+	sqlcodegen.From(user)
+	sqlcodegen.Update(user.UserName, userName)
+	sqlcodegen.Where(user.UserID == userID)
+}
@@ -0,0 +1,50 @@
+package join
+
+import "github.com/YiCodes/gosql/sqlcodegen"
+
+type User struct {
+	UserID   string
+	UserName string
+}
+
+type Order struct {
+	OrderID int64 `identity:"true"`
+	UserID  string
+	Amount  float64
+}
+
+type Item struct {
+	OrderID int64
+	Name    string
+}
+
+var (
+	user  User
+	order Order
+	item  Item
+)
+
+func GetUserOrders() {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserName, order.OrderID, order.Amount)
+	sqlcodegen.LeftJoin(order, order.UserID == user.UserID)
+}
+
+func GetOrderUsers() {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserName, order.OrderID)
+	sqlcodegen.RightJoin(order, order.UserID == user.UserID)
+}
+
+func GetUserOrderItems() {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserName, order.Amount, item.Name)
+	sqlcodegen.InnerJoin(order, order.UserID == user.UserID)
+	sqlcodegen.FullJoin(item, item.OrderID == order.OrderID)
+}
+
+func GetUserOrdersInner() {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserName, order.OrderID)
+	sqlcodegen.InnerJoin(order, order.UserID == user.UserID)
+}
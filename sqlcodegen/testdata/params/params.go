@@ -0,0 +1,24 @@
+package params
+
+import "github.com/YiCodes/gosql/sqlcodegen"
+
+type User struct {
+	UserID   int64
+	UserName string
+	Sex      byte
+}
+
+var user User
+
+func GetUsersBetween(minID, maxID int64, sex byte) {
+	sqlcodegen.From(user)
+	sqlcodegen.SelectAll(user)
+	sqlcodegen.Where(user.UserID >= minID && user.UserID <= maxID && user.Sex == sex)
+}
+
+func GetUserPage(page, size int) {
+	sqlcodegen.From(user)
+	sqlcodegen.SelectAll(user)
+	sqlcodegen.OrderBy(user.UserID)
+	sqlcodegen.Paginate(page, size)
+}
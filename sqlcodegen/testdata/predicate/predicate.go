@@ -0,0 +1,46 @@
+package predicate
+
+import (
+	"database/sql"
+
+	"github.com/YiCodes/gosql/sqlcodegen"
+)
+
+type User struct {
+	UserID   int64
+	UserName string
+	Sex      byte
+	Nick     sql.NullString
+}
+
+var user User
+
+func GetUsersByIDs(ids []int64, pattern string) {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserID)
+	sqlcodegen.Where(sqlcodegen.In(user.UserID, ids) && sqlcodegen.Like(user.UserName, pattern))
+}
+
+func GetOtherUsers(names []string, pattern string) {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserID)
+	sqlcodegen.Where(sqlcodegen.NotIn(user.UserName, names) || sqlcodegen.NotLike(user.UserName, pattern))
+}
+
+func GetUsersBySex(from int64, to int64) {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserID)
+	sqlcodegen.Where(sqlcodegen.In(user.Sex, []byte{1, 2}) && sqlcodegen.Between(user.UserID, from, to))
+}
+
+func GetUsersWithoutNick() {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserID)
+	sqlcodegen.Where(sqlcodegen.IsNull(user.Nick) || sqlcodegen.NotNull(user.UserName))
+}
+
+func ClearNick(userID int64) {
+	sqlcodegen.From(user)
+	sqlcodegen.Update(user.Nick, nil)
+	sqlcodegen.Where(user.UserID == userID)
+}
@@ -0,0 +1,19 @@
+package procedure
+
+import "github.com/YiCodes/gosql/sqlcodegen"
+
+type User struct {
+	UserID   int64
+	UserName string
+}
+
+var user User
+
+func DeleteUserProc(userID int64) {
+	sqlcodegen.ExecProcedure("sp_delete_user", userID, 1)
+}
+
+func ListUsersProc(userName string) {
+	sqlcodegen.ExecProcedure("sp_list_users", userName)
+	sqlcodegen.SelectAll(user)
+}
@@ -0,0 +1,7 @@
+package procout
+
+import "github.com/YiCodes/gosql/sqlcodegen"
+
+func AddUserProc(userName string, userID *int64) {
+	sqlcodegen.ExecProcedure("sp_add_user", userName, sqlcodegen.Out(userID))
+}
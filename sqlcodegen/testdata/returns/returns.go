@@ -0,0 +1,51 @@
+package returns
+
+import "github.com/YiCodes/gosql/sqlcodegen"
+
+type User struct {
+	UserID   int64
+	UserName string
+	Sex      byte
+}
+
+var user User
+
+func GetUser(userID int64) {
+	sqlcodegen.From(user)
+	sqlcodegen.SelectAll(user)
+	sqlcodegen.Where(user.UserID == userID)
+	sqlcodegen.SetReturnType(sqlcodegen.ReturnRecord)
+}
+
+func GetUsers(sex byte) {
+	sqlcodegen.From(user)
+	sqlcodegen.SelectAll(user)
+	sqlcodegen.Where(user.Sex == sex)
+	sqlcodegen.SetReturnType(sqlcodegen.ReturnRecordSet)
+}
+
+func GetUserName(userID int64) {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserName)
+	sqlcodegen.Where(user.UserID == userID)
+	sqlcodegen.SetReturnType(sqlcodegen.ReturnScalar)
+}
+
+func GetUserNames(sex byte) {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserName)
+	sqlcodegen.Where(user.Sex == sex)
+	sqlcodegen.SetReturnType(sqlcodegen.ReturnScalarSet)
+}
+
+func StreamUsers() {
+	sqlcodegen.From(user)
+	sqlcodegen.SelectAll(user)
+	sqlcodegen.SetReturnType(sqlcodegen.ReturnRecordChannel)
+}
+
+func IterUsers() {
+	sqlcodegen.From(user)
+	sqlcodegen.SelectAll(user)
+	sqlcodegen.SetReturnType(sqlcodegen.ReturnRecordIter)
+}
@@ -0,0 +1,5 @@
+//go:build ignore
+
+package shared
+
+func GetUser() {}
@@ -0,0 +1,23 @@
+package shared
+
+import "github.com/YiCodes/gosql/sqlcodegen"
+
+type User struct {
+	UserID   string
+	UserName string
+}
+
+type Order struct {
+	OrderID int64
+	UserID  string
+	Amount  int64
+}
+
+var (
+	user  User
+	order Order
+)
+
+func init() {
+	sqlcodegen.SetPackageName("gen")
+}
@@ -0,0 +1,11 @@
+package shared
+
+import "github.com/YiCodes/gosql/sqlcodegen"
+
+func GetLargeOrders(amount int64) {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserName, order.OrderID, order.Amount)
+	sqlcodegen.InnerJoin(order, order.UserID == user.UserID)
+	sqlcodegen.Where(order.Amount > amount)
+	sqlcodegen.SetResultTypeName("UserOrder")
+}
@@ -0,0 +1,18 @@
+package shared
+
+import "github.com/YiCodes/gosql/sqlcodegen"
+
+func GetUser(userID string) {
+	sqlcodegen.From(user)
+	sqlcodegen.SelectAll(user)
+	sqlcodegen.Where(user.UserID == userID)
+	sqlcodegen.SetReturnType(sqlcodegen.ReturnRecord)
+}
+
+func GetUserOrders(userID string) {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserName, order.OrderID, order.Amount)
+	sqlcodegen.InnerJoin(order, order.UserID == user.UserID)
+	sqlcodegen.Where(user.UserID == userID)
+	sqlcodegen.SetResultTypeName("UserOrder")
+}
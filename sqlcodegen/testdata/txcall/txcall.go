@@ -0,0 +1,61 @@
+package txcall
+
+import "github.com/YiCodes/gosql/sqlcodegen"
+
+type Order struct {
+	OrderID int64 `identity:"true"`
+	UserID  string
+	Amount  float64
+}
+
+type Stock struct {
+	ProductID string
+	Quantity  int
+}
+
+var (
+	order Order
+	stock Stock
+)
+
+// PlaceOrder 多语句的事务函数，返回sqlutil.Results
+func PlaceOrder(o *Order, productID string, quantity int) {
+	sqlcodegen.Transactional()
+	{
+		sqlcodegen.InsertAll(order)
+	}
+	{
+		sqlcodegen.From(stock)
+		sqlcodegen.Update(stock.Quantity, quantity)
+		sqlcodegen.Where(stock.ProductID == productID)
+	}
+}
+
+func DeleteOrders(userID string) {
+	sqlcodegen.Delete(order)
+	sqlcodegen.Where(order.UserID == userID)
+}
+
+// ReplaceOrders 只调用其他描述函数的事务函数，只返回error
+func ReplaceOrders(o *Order, userID string, productID string, quantity int) {
+	sqlcodegen.Transactional()
+	DeleteOrders(userID)
+	PlaceOrder(o, productID, quantity)
+}
+
+func ReplaceOrdersTwice(o *Order, userID string, productID string, quantity int) {
+	sqlcodegen.Transactional()
+	ReplaceOrders(o, userID, productID, quantity)
+	PlaceOrder(o, productID, quantity)
+}
+
+func InsertOrder() {
+	sqlcodegen.InsertAll(order)
+}
+
+// SaveOrder 调用InsertAll函数时传入类型为*Order的参数
+func SaveOrder(userID string, o *Order) {
+	sqlcodegen.Transactional()
+	DeleteOrders(userID)
+	InsertOrder()
+}
@@ -0,0 +1,13 @@
+package warn
+
+import "github.com/YiCodes/gosql/sqlcodegen"
+
+type User struct {
+	UserID int64
+}
+
+var user User
+
+func init() {
+	sqlcodegen.From(user)
+}
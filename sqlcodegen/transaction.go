@@ -0,0 +1,203 @@
+package sqlcodegen
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// genTransactionalFunction 生成在一个事务中依次调用其他描述函数的函数，
+// 函数体中除Transactional()之外只能调用同一个包中的描述函数，参数只能是函数的参数或常量
+func genTransactionalFunction(context *parseContext, funcDecl *ast.FuncDecl) error {
+	funcName := funcDecl.Name.Name
+	descFuncs := getDescriptionFunctions(context)
+
+	params := make(map[string]bool)
+
+	for _, field := range funcDecl.Type.Params.List {
+		for _, name := range field.Names {
+			params[name.Name] = true
+		}
+	}
+
+	var calls []string
+
+	for _, stmt := range funcDecl.Body.List {
+		exprStmt, ok := stmt.(*ast.ExprStmt)
+
+		if !ok {
+			return context.newError(stmt, "unsupported statement in transactional function %s", funcName)
+		}
+
+		callExpr, ok := exprStmt.X.(*ast.CallExpr)
+
+		if !ok {
+			return context.newError(stmt, "unsupported statement in transactional function %s", funcName)
+		}
+
+		switch fun := callExpr.Fun.(type) {
+		case *ast.SelectorExpr:
+			if fun.Sel.Name != "Transactional" {
+				return context.newError(callExpr, "%s cannot be used in transactional function %s, call a description function instead",
+					types.ExprString(fun), funcName)
+			}
+
+			if len(callExpr.Args) != 0 {
+				return newArgError(context, callExpr)
+			}
+		case *ast.Ident:
+			callee, ok := descFuncs[fun.Name]
+
+			if !ok {
+				return context.newError(fun, "%s is not a description function", fun.Name)
+			}
+
+			if callee == funcDecl {
+				return context.newError(fun, "transactional function %s cannot call itself", funcName)
+			}
+
+			if hasReturnType(callee, "ReturnRecordChannel") || hasReturnType(callee, "ReturnRecordIter") {
+				return context.newError(fun, "%s reads rows lazily and cannot be called in a transaction", fun.Name)
+			}
+
+			objParam, err := getTransactionInsertParam(context, funcDecl, callee, callExpr)
+
+			if err != nil {
+				return err
+			}
+
+			call, err := getTransactionCallCode(context, callExpr, params, objParam)
+
+			if err != nil {
+				return err
+			}
+
+			if getFunctionReturnsResult(callee) {
+				calls = append(calls, "_, err := "+call)
+			} else {
+				calls = append(calls, "err := "+call)
+			}
+		default:
+			return newUnsupportedError(context, callExpr)
+		}
+	}
+
+	if len(calls) == 0 {
+		return context.newError(funcDecl.Name, "transactional function %s does not call any description function", funcName)
+	}
+
+	generator := context.generator
+
+	genMethodBegin(context, funcName, funcDecl.Type.Params.List, nil, funcDecl.Doc)
+
+	generator.write("return sqlutil.WithTx(" + getContextArg(context) + ", db, " + getTxOptionsCode(context) + ", func(tx sqlutil.DbObject) error")
+	generator.beginBlock()
+
+	for _, call := range calls {
+		generator.write("if " + call + "; err != nil")
+		generator.beginBlock()
+		generator.writeLine("return err")
+		generator.endBlock()
+	}
+
+	generator.writeLine("return nil")
+	generator.endBlock(")")
+
+	genMethodEnd(context)
+
+	return nil
+}
+
+// getTxOptionsCode 返回传给WithTx的选项。SQL Server的保存点语法不同，
+// 指定BindAt后生成的函数在调用者传入的*sql.Tx中同样可以使用保存点
+func getTxOptionsCode(context *parseContext) string {
+	if builder, ok := context.sqlBuilder.(dynamicSQLBuilder); ok && builder.getBindType() == "sqlutil.BindAt" {
+		return "&sqlutil.TxOptions{BindType: sqlutil.BindAt}"
+	}
+
+	return "nil"
+}
+
+// getTransactionInsertParam callee为InsertAll等插入对象字段的函数时，返回事务函数中传给callee的*T参数，
+// 描述函数callee没有参数，无法在调用时写出插入的对象
+func getTransactionInsertParam(context *parseContext, funcDecl *ast.FuncDecl, callee *ast.FuncDecl, callExpr *ast.CallExpr) (string, error) {
+	if getStatementBlocks(callee) != nil {
+		return "", nil
+	}
+
+	insertCall, objName := getInsertCall(callee)
+
+	if insertCall == nil || objName == "" {
+		return "", nil
+	}
+
+	entity, _, err := getInsertColumns(context, insertCall)
+
+	if err != nil {
+		return "", err
+	}
+
+	paramName, isSlice, err := getEntityParam(context, funcDecl, entity, callExpr)
+
+	if err != nil {
+		return "", err
+	}
+
+	if paramName == "" || isSlice {
+		return "", context.newError(callExpr, "%s inserts a *%s, transactional function %s needs a parameter of type *%s",
+			types.ExprString(callExpr.Fun), entity.name, funcDecl.Name.Name, entity.name)
+	}
+
+	return paramName, nil
+}
+
+// getTransactionCallCode 返回在事务中调用描述函数的代码，db替换为tx，objParam不为空时作为插入的对象传给被调用的函数
+func getTransactionCallCode(context *parseContext, callExpr *ast.CallExpr, params map[string]bool, objParam string) (string, error) {
+	var args []string
+
+	if context.useContext {
+		args = append(args, "ctx")
+	}
+
+	args = append(args, "tx")
+
+	for _, arg := range callExpr.Args {
+		switch inst := arg.(type) {
+		case *ast.Ident:
+			if !params[inst.Name] && !isNilIdent(inst) && inst.Name != "true" && inst.Name != "false" {
+				return "", context.newError(inst, "%s is not a parameter of the function", inst.Name)
+			}
+		case *ast.BasicLit:
+		case *ast.UnaryExpr:
+			if lit, ok := inst.X.(*ast.BasicLit); !ok || inst.Op != token.SUB || lit.Kind == token.STRING {
+				return "", newUnsupportedError(context, arg)
+			}
+		default:
+			return "", newUnsupportedError(context, arg)
+		}
+
+		args = append(args, types.ExprString(arg))
+	}
+
+	if objParam != "" {
+		args = append(args, objParam)
+	}
+
+	return types.ExprString(callExpr.Fun) + "(" + strings.Join(args, ", ") + ")", nil
+}
+
+// getDescriptionFunctions 返回包中所有的描述函数
+func getDescriptionFunctions(context *parseContext) map[string]*ast.FuncDecl {
+	result := make(map[string]*ast.FuncDecl)
+
+	for _, file := range context.files {
+		for _, decl := range file.Decls {
+			if inst, ok := decl.(*ast.FuncDecl); ok && inst.Recv == nil && inst.Name.Name != "init" {
+				result[inst.Name.Name] = inst
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,90 @@
+package sqlcodegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTransactionalCallShape(t *testing.T) {
+	for _, dialect := range []Dialect{DialectDefault, DialectPostgres, DialectSQLServer} {
+		code := generateTestPackage(t, "txcall", Options{Dialect: dialect})
+
+		for _, want := range []string{
+			"if _, err := DeleteOrders(ctx, tx, userID); err != nil {",
+			"if _, err := PlaceOrder(ctx, tx, o, productID, quantity); err != nil {",
+			"if err := ReplaceOrders(ctx, tx, o, userID, productID, quantity); err != nil {",
+			"if _, err := InsertOrder(ctx, tx, o); err != nil {",
+		} {
+			if !strings.Contains(code, want) {
+				t.Errorf("%s: generated code does not contain %s\n%s", dialect, want, code)
+			}
+		}
+	}
+}
+
+func TestTransactionalInsertWithoutParam(t *testing.T) {
+	src := setTestGOPATH(t)
+	descDir := filepath.Join(src, "desc")
+
+	writeTestFile(t, filepath.Join(descDir, "desc.go"), `package desc
+
+import "github.com/YiCodes/gosql/sqlcodegen"
+
+type Order struct {
+	OrderID int64 `+"`identity:\"true\"`"+`
+	UserID  string
+}
+
+var order Order
+
+func InsertOrder() {
+	sqlcodegen.InsertAll(order)
+}
+
+func SaveOrder(userID string) {
+	sqlcodegen.Transactional()
+	InsertOrder()
+}
+`)
+
+	_, err := GeneratePackage(descDir, Options{})
+
+	if err == nil || !strings.Contains(err.Error(), "desc.go:18:2: error: InsertOrder inserts a *Order, transactional function SaveOrder needs a parameter of type *Order") {
+		t.Errorf("GeneratePackage = %v, want an error about the missing *Order parameter", err)
+	}
+}
+
+func TestCheckGeneratedCode(t *testing.T) {
+	fileNames, err := GetModelFiles(filepath.Join("testdata", "txcall"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context, err := loadModelPackage(fileNames, Options{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := []*GeneratedFile{{Name: "txcall.go", Content: []byte(`package txcall
+
+func F() error {
+	err := G()
+	return err
+}
+
+func G() (int, error) {
+	return 0, nil
+}
+`)}}
+
+	checkGeneratedCode(context, filepath.Join("testdata", "txcall"), files)
+
+	err = context.finish(nil)
+
+	if err == nil || !strings.Contains(err.Error(), "txcall.go:4:9: error: generated code does not compile: assignment mismatch") {
+		t.Errorf("checkGeneratedCode = %v, want an assignment mismatch at txcall.go:4:9", err)
+	}
+}
@@ -0,0 +1,625 @@
+package sqlcodegen
+
+import (
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+)
+
+const sqlcodegenPath = "github.com/YiCodes/gosql/sqlcodegen"
+
+// descSource 描述文件使用的API，类型检查时代替sqlcodegen包，避免从源代码加载整个生成器
+//
+//go:embed desc.go
+var descSource string
+
+// modelImporter 标准库使用编译器的导出数据，其他包从源代码加载，保证同一个包只有一份类型信息。
+// 每次编译使用新的modelImporter，-watch模式下可以读取到导入的模型包的修改
+type modelImporter struct {
+	fset     *token.FileSet
+	gc       types.Importer
+	packages map[string]*types.Package
+}
+
+func newModelImporter() *modelImporter {
+	return &modelImporter{
+		fset:     token.NewFileSet(),
+		gc:       importer.Default(),
+		packages: make(map[string]*types.Package),
+	}
+}
+
+func (imp *modelImporter) Import(path string) (*types.Package, error) {
+	return imp.ImportFrom(path, "", 0)
+}
+
+func (imp *modelImporter) ImportFrom(path string, dir string, mode types.ImportMode) (*types.Package, error) {
+	if pkg, ok := imp.packages[path]; ok {
+		return pkg, nil
+	}
+
+	var pkg *types.Package
+	var err error
+
+	if path == sqlcodegenPath {
+		pkg, err = imp.importSource(path, []string{"desc.go"}, []string{descSource})
+	} else if pkg, err = imp.gc.Import(path); err != nil {
+		var bp *build.Package
+
+		if bp, err = build.Default.Import(path, dir, 0); err != nil {
+			return nil, err
+		}
+
+		pkg, err = imp.importDir(bp)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	imp.packages[path] = pkg
+
+	return pkg, nil
+}
+
+func (imp *modelImporter) importDir(bp *build.Package) (*types.Package, error) {
+	fileNames := make([]string, len(bp.GoFiles))
+
+	for i, name := range bp.GoFiles {
+		fileNames[i] = filepath.Join(bp.Dir, name)
+	}
+
+	return imp.importSource(bp.ImportPath, fileNames, nil)
+}
+
+func (imp *modelImporter) importSource(path string, fileNames []string, sources []string) (*types.Package, error) {
+	var files []*ast.File
+
+	for i, name := range fileNames {
+		var src interface{}
+
+		if sources != nil {
+			src = sources[i]
+		}
+
+		file, err := parser.ParseFile(imp.fset, name, src, 0)
+
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	conf := types.Config{Importer: imp, IgnoreFuncBodies: true}
+
+	return conf.Check(path, imp.fset, files, nil)
+}
+
+// typeCheckPackage 使用go/types检查描述文件组成的包，并校验sqlcodegen调用中列与参数的类型，
+// 类型错误记录在context.diagnostics中
+func typeCheckPackage(context *parseContext, files []*ast.File) error {
+	var typeErrors []types.Error
+
+	context.importer = newModelImporter()
+
+	conf := types.Config{
+		Importer: context.importer,
+		Error: func(err error) {
+			typeErrors = append(typeErrors, err.(types.Error))
+		},
+	}
+
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+
+	pkg, _ := conf.Check(files[0].Name.Name, context.fset, files, info)
+
+	for _, e := range typeErrors {
+		if strings.Contains(e.Msg, "could not import") {
+			context.diagnostics = append(context.diagnostics,
+				context.newDiagnostic(SeverityWarning, e.Pos, "%s, skip type check", e.Msg))
+
+			return nil
+		}
+	}
+
+	context.typeInfo = info
+	context.pkg = pkg
+
+	if err := addImportedEntities(context, files); err != nil {
+		return err
+	}
+
+	checker := &dslChecker{context: context, info: info, typeErrors: typeErrors}
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Body != nil {
+				checker.checkFunction(funcDecl)
+			}
+		}
+	}
+
+	// 未使用的变量与导入等不影响生成代码的错误忽略
+	for _, e := range typeErrors {
+		if !e.Soft && !checker.isReported(e.Pos) {
+			context.diagnostics = append(context.diagnostics, context.newDiagnostic(SeverityError, e.Pos, "%s", e.Msg))
+		}
+	}
+
+	return nil
+}
+
+// dirImporter 从dir查找导入的包，生成的代码还没有写入输出目录，使用描述文件所在的目录
+type dirImporter struct {
+	*modelImporter
+	dir string
+}
+
+func (imp dirImporter) ImportFrom(path string, dir string, mode types.ImportMode) (*types.Package, error) {
+	return imp.modelImporter.ImportFrom(path, imp.dir, mode)
+}
+
+// checkGeneratedCode 使用go/types检查生成的代码，生成器无法生成正确的代码时报告错误，
+// 而不是写入无法编译的文件或者让-check通过
+func checkGeneratedCode(context *parseContext, srcDir string, files []*GeneratedFile) {
+	if context.importer == nil {
+		return
+	}
+
+	fset := token.NewFileSet()
+	sources := make(map[string][]byte)
+
+	var astFiles []*ast.File
+
+	for _, f := range files {
+		sources[f.Name] = f.Content
+
+		file, err := parser.ParseFile(fset, f.Name, f.Content, 0)
+
+		if err != nil {
+			context.addError(fmt.Errorf("generated code does not compile: %v", err))
+			return
+		}
+
+		astFiles = append(astFiles, file)
+	}
+
+	var typeErrors []types.Error
+
+	conf := types.Config{
+		Importer: dirImporter{context.importer, srcDir},
+		Error: func(err error) {
+			typeErrors = append(typeErrors, err.(types.Error))
+		},
+	}
+
+	conf.Check(context.packageName, fset, astFiles, nil)
+
+	for _, e := range typeErrors {
+		if strings.Contains(e.Msg, "could not import") {
+			context.diagnostics = append(context.diagnostics,
+				&Diagnostic{Severity: SeverityWarning, Message: e.Msg + ", skip type check of generated code"})
+
+			return
+		}
+	}
+
+	for _, e := range typeErrors {
+		pos := e.Fset.Position(e.Pos)
+
+		context.diagnostics = append(context.diagnostics, &Diagnostic{
+			Pos:      pos,
+			Severity: SeverityError,
+			Message:  "generated code does not compile: " + e.Msg,
+			Snippet:  getLine(sources[pos.Filename], pos.Line),
+		})
+	}
+}
+
+// addImportedEntities 注册类型为其他包中模型的实体，如 var user models.User
+func addImportedEntities(context *parseContext, files []*ast.File) error {
+	imported := make(map[string]*table)
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+
+			if !ok || genDecl.Tok != token.VAR {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					if _, ok := context.entity[name.Name]; ok {
+						continue
+					}
+
+					obj, ok := context.typeInfo.Defs[name]
+
+					if !ok || obj == nil {
+						continue
+					}
+
+					named, ok := obj.Type().(*types.Named)
+
+					if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg() == context.pkg {
+						continue
+					}
+
+					structType, ok := named.Underlying().(*types.Struct)
+
+					if !ok {
+						continue
+					}
+
+					typeName := named.Obj().Pkg().Name() + "." + named.Obj().Name()
+					t, ok := imported[typeName]
+
+					if !ok {
+						var err error
+
+						if t, err = typesToTable(context, typeName, structType, genDecl); err != nil {
+							return err
+						}
+
+						imported[typeName] = t
+					}
+
+					context.entity[name.Name] = t
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func typesToTable(context *parseContext, typeName string, structType *types.Struct, source *ast.GenDecl) (*table, error) {
+	t := &table{name: typeName, source: source}
+	qualifier := func(p *types.Package) string {
+		if p == context.pkg {
+			return ""
+		}
+
+		return p.Name()
+	}
+
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		tag := "`" + structType.Tag(i) + "`"
+
+		if field.Embedded() && strings.HasSuffix(field.Type().String(), "TableName") {
+			if name, ok := getTags(tag)["tableName"]; ok {
+				t.tableName = name
+			}
+
+			continue
+		}
+
+		if !field.Exported() {
+			continue
+		}
+
+		col := &column{table: t, name: field.Name()}
+		col.sysType = types.TypeString(field.Type(), qualifier)
+
+		switch fieldType := field.Type().(type) {
+		case *types.Pointer:
+			col.isNull = true
+		case *types.Named:
+			col.isNull = fieldType.Obj().Pkg() != nil && fieldType.Obj().Pkg().Path() == "database/sql" &&
+				strings.HasPrefix(fieldType.Obj().Name(), "Null")
+		}
+
+		if err := setColumnTags(col, tag); err != nil {
+			return nil, newTypeDefError(context, typeName, source)
+		}
+
+		if col.columnName == "" {
+			col.columnName = col.name
+		}
+
+		t.columns = append(t.columns, col)
+	}
+
+	if t.tableName == "" {
+		t.tableName = typeName[strings.LastIndex(typeName, ".")+1:]
+	}
+
+	return t, nil
+}
+
+func sameFile(a string, b string) bool {
+	a, errA := filepath.Abs(a)
+	b, errB := filepath.Abs(b)
+
+	return errA == nil && errB == nil && a == b
+}
+
+// dslChecker 校验sqlcodegen调用的参数，go/types允许的interface{}参数同样需要与列的类型一致
+type dslChecker struct {
+	context    *parseContext
+	info       *types.Info
+	funcDecl   *ast.FuncDecl
+	typeErrors []types.Error
+	reported   []ast.Node
+}
+
+func (c *dslChecker) isReported(pos token.Pos) bool {
+	for _, node := range c.reported {
+		if node.Pos() <= pos && pos < node.End() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *dslChecker) report(node ast.Node, format string, args ...interface{}) {
+	if c.isReported(node.Pos()) {
+		return
+	}
+
+	c.reported = append(c.reported, node)
+	c.context.addError(c.context.newError(node, format, args...))
+}
+
+func (c *dslChecker) checkFunction(funcDecl *ast.FuncDecl) {
+	for _, block := range getStatementBlocks(funcDecl) {
+		c.checkFunction(block)
+	}
+
+	c.funcDecl = funcDecl
+
+	for callExpr := range getCallExprList(funcDecl) {
+		fun := callExpr.Fun.(*ast.SelectorExpr)
+
+		switch fun.Sel.Name {
+		case "Where", "Having":
+			for _, arg := range callExpr.Args {
+				c.checkCondition(arg)
+			}
+		case "InnerJoin", "LeftJoin", "RightJoin", "FullJoin":
+			if len(callExpr.Args) == 2 {
+				c.checkCondition(callExpr.Args[1])
+			}
+		case "Update":
+			if len(callExpr.Args) == 2 {
+				c.checkAssign(callExpr.Args[0], callExpr.Args[1])
+			}
+		case "Limit", "Offset", "Paginate":
+			for _, arg := range callExpr.Args {
+				c.checkInteger(fun.Sel.Name, arg)
+			}
+		}
+	}
+}
+
+func (c *dslChecker) checkCondition(expr ast.Expr) {
+	ast.Inspect(expr, func(node ast.Node) bool {
+		switch inst := node.(type) {
+		case *ast.BinaryExpr:
+			switch inst.Op {
+			case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+				c.checkCompare(inst, inst.X, inst.Y)
+
+				return false
+			}
+		case *ast.CallExpr:
+			fun, ok := inst.Fun.(*ast.SelectorExpr)
+
+			if !ok {
+				return true
+			}
+
+			switch fun.Sel.Name {
+			case "In", "NotIn":
+				if len(inst.Args) == 2 {
+					c.checkCompareElement(inst, inst.Args[0], inst.Args[1])
+				}
+			case "Like", "NotLike":
+				if len(inst.Args) == 2 {
+					c.checkCompare(inst, inst.Args[0], inst.Args[1])
+					c.checkString(fun.Sel.Name, inst.Args[0])
+				}
+			case "Between":
+				if len(inst.Args) == 3 {
+					c.checkCompare(inst, inst.Args[0], inst.Args[1])
+					c.checkCompare(inst, inst.Args[0], inst.Args[2])
+				}
+			}
+		}
+
+		return true
+	})
+}
+
+func (c *dslChecker) checkCompare(node ast.Node, x ast.Expr, y ast.Expr) {
+	column, value := x, y
+
+	if _, ok := c.context.getColumnWithExpr(x); !ok {
+		if _, ok := c.context.getColumnWithExpr(y); !ok {
+			return
+		}
+
+		column, value = y, x
+	}
+
+	if !c.isValid(column) || !c.isValid(value) {
+		return
+	}
+
+	if isNilIdent(value) {
+		if c.hasTypeError(node) {
+			c.report(node, "cannot compare %s with nil, use sqlcodegen.IsNull or sqlcodegen.NotNull", c.describe(column))
+		}
+
+		return
+	}
+
+	if c.hasTypeError(node) || !isCompatibleType(c.typeOf(column), c.typeOf(value)) {
+		c.report(node, "cannot compare %s with %s", c.describe(column), c.describe(value))
+	}
+}
+
+func (c *dslChecker) checkCompareElement(node ast.Node, column ast.Expr, values ast.Expr) {
+	if _, ok := c.context.getColumnWithExpr(column); !ok || !c.isValid(values) {
+		return
+	}
+
+	var elem types.Type
+
+	switch t := c.typeOf(values).Underlying().(type) {
+	case *types.Slice:
+		elem = t.Elem()
+	case *types.Array:
+		elem = t.Elem()
+	default:
+		c.report(node, "%s requires a slice or array of values, got %s", node.(*ast.CallExpr).Fun.(*ast.SelectorExpr).Sel.Name, c.describe(values))
+		return
+	}
+
+	if !isCompatibleType(c.typeOf(column), elem) {
+		c.report(node, "cannot compare %s with %s", c.describe(column), c.describe(values))
+	}
+}
+
+func (c *dslChecker) checkAssign(column ast.Expr, value ast.Expr) {
+	if _, ok := c.context.getColumnWithExpr(column); !ok {
+		return
+	}
+
+	if c.isValid(value) && !isCompatibleType(c.typeOf(column), c.typeOf(value)) {
+		c.report(value, "cannot assign %s to %s", c.describe(value), c.describe(column))
+	}
+}
+
+func (c *dslChecker) checkInteger(name string, expr ast.Expr) {
+	t := c.typeOf(expr)
+
+	if t == nil {
+		return
+	}
+
+	if basic, ok := t.Underlying().(*types.Basic); !ok || basic.Info()&types.IsInteger == 0 {
+		c.report(expr, "%s requires an integer, got %s", name, c.describe(expr))
+	}
+}
+
+func (c *dslChecker) checkString(name string, column ast.Expr) {
+	if _, ok := c.context.getColumnWithExpr(column); !ok {
+		return
+	}
+
+	if class := getTypeClass(c.typeOf(column)); class != "" && class != "string" {
+		c.report(column, "%s requires a string column, got %s", name, c.describe(column))
+	}
+}
+
+func (c *dslChecker) hasTypeError(node ast.Node) bool {
+	for _, e := range c.typeErrors {
+		if !e.Soft && node.Pos() <= e.Pos && e.Pos < node.End() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isValid 返回表达式的类型是否有效，无效的表达式已由go/types报告错误
+func (c *dslChecker) isValid(expr ast.Expr) bool {
+	t := c.typeOf(expr)
+
+	return t != nil && t != types.Typ[types.Invalid]
+}
+
+func (c *dslChecker) typeOf(expr ast.Expr) types.Type {
+	if tv, ok := c.info.Types[expr]; ok {
+		return tv.Type
+	}
+
+	return nil
+}
+
+// describe 返回表达式的描述，如 byte column Sex、string parameter userName
+func (c *dslChecker) describe(expr ast.Expr) string {
+	typeName := "unknown"
+
+	if t := c.typeOf(expr); t != nil {
+		typeName = types.TypeString(t, func(p *types.Package) string { return p.Name() })
+	}
+
+	if col, ok := c.context.getColumnWithExpr(expr); ok {
+		return typeName + " column " + col.source.name
+	}
+
+	if ident, ok := expr.(*ast.Ident); ok {
+		if _, ok := getFuncParamNames(c.funcDecl)[ident.Name]; ok {
+			return typeName + " parameter " + ident.Name
+		}
+	}
+
+	return typeName + " value " + types.ExprString(expr)
+}
+
+// getTypeClass 返回类型对应的SQL值的类别，指针与sql.Null类型按照其值的类型处理，无法判断时返回空字符串
+func getTypeClass(t types.Type) string {
+	if t == nil {
+		return ""
+	}
+
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil {
+		switch named.Obj().Pkg().Path() + "." + named.Obj().Name() {
+		case "time.Time":
+			return "time"
+		}
+
+		if named.Obj().Pkg().Path() == "database/sql" && strings.HasPrefix(named.Obj().Name(), "Null") {
+			if s, ok := named.Underlying().(*types.Struct); ok && s.NumFields() > 0 {
+				return getTypeClass(s.Field(0).Type())
+			}
+		}
+	}
+
+	switch inst := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case inst.Kind() == types.UntypedNil:
+			return ""
+		case inst.Info()&types.IsNumeric != 0:
+			return "number"
+		case inst.Info()&types.IsString != 0:
+			return "string"
+		case inst.Info()&types.IsBoolean != 0:
+			return "bool"
+		}
+	case *types.Slice:
+		if basic, ok := inst.Elem().Underlying().(*types.Basic); ok && basic.Kind() == types.Byte {
+			return "bytes"
+		}
+	}
+
+	return ""
+}
+
+func isCompatibleType(a types.Type, b types.Type) bool {
+	classA, classB := getTypeClass(a), getTypeClass(b)
+
+	return classA == "" || classB == "" || classA == classB
+}
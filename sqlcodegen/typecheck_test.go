@@ -0,0 +1,68 @@
+package sqlcodegen
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, fileName string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(fileName), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(fileName, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setTestGOPATH 使用临时目录作为GOPATH，返回其中的src目录
+func setTestGOPATH(t *testing.T) string {
+	gopath := t.TempDir()
+	old := build.Default.GOPATH
+
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("GO111MODULE", "off")
+	build.Default.GOPATH = gopath
+	t.Cleanup(func() { build.Default.GOPATH = old })
+
+	return filepath.Join(gopath, "src")
+}
+
+func TestTypeCheckReloadsImportedModels(t *testing.T) {
+	src := setTestGOPATH(t)
+	modelFile := filepath.Join(src, "models", "models.go")
+	descDir := filepath.Join(src, "desc")
+
+	writeTestFile(t, modelFile, "package models\n\ntype User struct {\n\tUserID int64\n}\n")
+	writeTestFile(t, filepath.Join(descDir, "desc.go"), `package desc
+
+import (
+	"github.com/YiCodes/gosql/sqlcodegen"
+	"models"
+)
+
+var user models.User
+
+func GetUserName(userID int64) {
+	sqlcodegen.From(user)
+	sqlcodegen.Select(user.UserName)
+	sqlcodegen.Where(user.UserID == userID)
+}
+`)
+
+	if _, err := GeneratePackage(descDir, Options{}); err == nil || !strings.Contains(err.Error(), "UserName") {
+		t.Fatalf("GeneratePackage before UserName is added: %v, want an error about UserName", err)
+	}
+
+	writeTestFile(t, modelFile, "package models\n\ntype User struct {\n\tUserID   int64\n\tUserName string\n}\n")
+
+	if _, err := GeneratePackage(descDir, Options{}); err != nil {
+		t.Fatalf("GeneratePackage after UserName is added: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+)
+
+// fakeDB 记录执行的语句的测试驱动，Query返回rows中的记录
+type fakeDB struct {
+	log          []string
+	failCommits  int
+	columns      []string
+	rows         [][]driver.Value
+	lastInsertID int64
+	lastRows     *fakeRows
+	// failQuery 执行这条语句时返回错误
+	failQuery string
+	// rowsErr 读取完rows中的记录后返回的错误
+	rowsErr error
+}
+
+func newFakeDB(t *testing.T) (*sql.DB, *fakeDB) {
+	f := &fakeDB{columns: []string{"n"}}
+	db := sql.OpenDB(fakeConnector{f})
+	db.SetMaxOpenConns(1)
+
+	t.Cleanup(func() { db.Close() })
+
+	return db, f
+}
+
+type serializationError struct{}
+
+func (serializationError) Error() string    { return "could not serialize access" }
+func (serializationError) SQLState() string { return "40001" }
+
+type fakeConnector struct{ db *fakeDB }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return nil, driver.ErrSkip }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	c.db.log = append(c.db.log, "BEGIN")
+	return fakeTx(c), nil
+}
+
+type fakeTx struct{ db *fakeDB }
+
+func (tx fakeTx) Commit() error {
+	if tx.db.failCommits > 0 {
+		tx.db.failCommits--
+		tx.db.log = append(tx.db.log, "COMMIT failed")
+		return serializationError{}
+	}
+
+	tx.db.log = append(tx.db.log, "COMMIT")
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.db.log = append(tx.db.log, "ROLLBACK")
+	return nil
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.db.log = append(s.db.log, s.query)
+
+	if s.query == s.db.failQuery {
+		return nil, errors.New("exec failed")
+	}
+
+	return fakeResult{s.db.lastInsertID}, nil
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.db.log = append(s.db.log, s.query)
+	s.db.lastRows = &fakeRows{columns: s.db.columns, rows: s.db.rows, err: s.db.rowsErr}
+	return s.db.lastRows, nil
+}
+
+type fakeResult struct{ lastInsertID int64 }
+
+func (r fakeResult) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return 1, nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+	closed  bool
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		if r.err != nil {
+			return r.err
+		}
+
+		return io.EOF
+	}
+
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MigrationTable 记录已执行的迁移版本
+const MigrationTable = "gosql_migrations"
+
+// Migration 一个版本的迁移脚本，文件名格式为<version>_<name>.up.sql
+type Migration struct {
+	Version string
+	Name    string
+	UpFile  string
+}
+
+// LoadMigrations 读取目录中的升级脚本，按版本排序
+func LoadMigrations(dir string) ([]*Migration, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	var list []*Migration
+
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), ".up.sql")
+		m := &Migration{Version: name, UpFile: f}
+
+		if i := strings.Index(name, "_"); i >= 0 {
+			m.Version, m.Name = name[:i], name[i+1:]
+		}
+
+		list = append(list, m)
+	}
+
+	return list, nil
+}
+
+// MigrateUp 依次执行目录中尚未执行的升级脚本，每个脚本在一个事务中执行，返回本次执行的版本。
+// MySQL等不支持事务性DDL的数据库在DDL语句处隐式提交，脚本中途失败时之前的语句不会回滚，版本也不会记录，
+// 返回的错误包含失败语句的序号，需要根据序号手动处理后再次执行
+func MigrateUp(ctx context.Context, db *sql.DB, bindType BindType, dir string) ([]string, error) {
+	migrations, err := LoadMigrations(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE "+MigrationTable+" (version VARCHAR(32) NOT NULL PRIMARY KEY)"); err != nil {
+		if _, e := db.ExecContext(ctx, "SELECT version FROM "+MigrationTable+" WHERE 1 = 0"); e != nil {
+			return nil, err
+		}
+	}
+
+	applied, err := getAppliedVersions(ctx, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+
+		if err := runMigration(ctx, db, bindType, m); err != nil {
+			return versions, err
+		}
+
+		versions = append(versions, m.Version)
+	}
+
+	return versions, nil
+}
+
+func getAppliedVersions(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version FROM "+MigrationTable)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+
+	for rows.Next() {
+		var version string
+
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+func runMigration(ctx context.Context, db *sql.DB, bindType BindType, m *Migration) error {
+	data, err := ioutil.ReadFile(m.UpFile)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	for i, stmt := range SplitStatements(string(data)) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("%s: statement %d: %v", filepath.Base(m.UpFile), i+1, err)
+		}
+	}
+
+	q := NewQuery(bindType)
+	q.Write("INSERT INTO "+MigrationTable+" (version) VALUES (?)", m.Version)
+
+	if _, err := tx.ExecContext(ctx, q.String(), q.Args()...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SplitStatements 按引号、注释以及PostgreSQL的$$函数体之外的分号拆分SQL脚本，忽略只有注释的语句
+func SplitStatements(script string) []string {
+	var list []string
+	var end string
+	start := 0
+
+	add := func(stmt string) {
+		if stmt = strings.TrimSpace(stmt); !isCommentOnly(stmt) {
+			list = append(list, stmt)
+		}
+	}
+
+	for i := 0; i < len(script); i++ {
+		if end != "" {
+			if strings.HasPrefix(script[i:], end) {
+				i += len(end) - 1
+				end = ""
+			}
+
+			continue
+		}
+
+		switch c := script[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			end = string(c)
+		case c == '[':
+			end = "]"
+		case strings.HasPrefix(script[i:], "--"):
+			end = "\n"
+		case strings.HasPrefix(script[i:], "/*"):
+			end = "*/"
+			i++
+		case c == '$':
+			if tag := getDollarQuoteTag(script[i:]); tag != "" {
+				end = tag
+				i += len(tag) - 1
+			}
+		case c == ';':
+			add(script[start:i])
+			start = i + 1
+		}
+	}
+
+	add(script[start:])
+
+	return list
+}
+
+// getDollarQuoteTag 返回s开头的PostgreSQL美元符号引用标记，如$$、$body$，$1等参数不是引用
+func getDollarQuoteTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80:
+		case c >= '0' && c <= '9' && i > 1:
+		default:
+			return ""
+		}
+	}
+
+	return ""
+}
+
+func isCommentOnly(stmt string) bool {
+	for {
+		stmt = strings.TrimSpace(stmt)
+
+		switch {
+		case stmt == "":
+			return true
+		case strings.HasPrefix(stmt, "--"):
+			if i := strings.IndexByte(stmt, '\n'); i >= 0 {
+				stmt = stmt[i+1:]
+			} else {
+				stmt = ""
+			}
+		case strings.HasPrefix(stmt, "/*"):
+			if i := strings.Index(stmt[2:], "*/"); i >= 0 {
+				stmt = stmt[i+4:]
+			} else {
+				stmt = ""
+			}
+		default:
+			return false
+		}
+	}
+}
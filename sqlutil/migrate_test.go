@@ -0,0 +1,59 @@
+package sqlutil
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		script string
+		want   []string
+	}{
+		{"", nil},
+		{"-- only a comment\n", nil},
+		{"CREATE TABLE a (id INT);\nDROP TABLE b;", []string{"CREATE TABLE a (id INT)", "DROP TABLE b"}},
+		{"INSERT INTO a VALUES ('x;y');", []string{"INSERT INTO a VALUES ('x;y')"}},
+		{"SELECT \"a;b\", `c;d`, [e;f] FROM t", []string{"SELECT \"a;b\", `c;d`, [e;f] FROM t"}},
+		{"-- drop; not really\nDROP TABLE a;\n-- trailing comment", []string{"-- drop; not really\nDROP TABLE a"}},
+		{"UPDATE a SET s = 'it''s';;", []string{"UPDATE a SET s = 'it''s'"}},
+		{"/* a; b */ DROP TABLE a; /* only; a comment */", []string{"/* a; b */ DROP TABLE a"}},
+		{"/* unterminated; comment", nil},
+		{"SELECT 1 /*/ ; */;SELECT 2", []string{"SELECT 1 /*/ ; */", "SELECT 2"}},
+		{"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;\nSELECT f();",
+			[]string{"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql", "SELECT f()"}},
+		{"DO $body$ BEGIN PERFORM 1; RAISE NOTICE '$$;'; END $body$;", []string{"DO $body$ BEGIN PERFORM 1; RAISE NOTICE '$$;'; END $body$"}},
+		{"SELECT $1; SELECT $2", []string{"SELECT $1", "SELECT $2"}},
+	}
+
+	for _, test := range tests {
+		if got := SplitStatements(test.script); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SplitStatements(%q) = %q, want %q", test.script, got, test.want)
+		}
+	}
+}
+
+func TestMigrateUpReportsFailedStatement(t *testing.T) {
+	dir := t.TempDir()
+	script := "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n"
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "001_init.up.sql"), []byte(script), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	db, f := newFakeDB(t)
+	f.failQuery = "CREATE TABLE b (id INT)"
+
+	versions, err := MigrateUp(context.Background(), db, BindQuestion, dir)
+
+	if len(versions) != 0 || err == nil || err.Error() != "001_init.up.sql: statement 2: exec failed" {
+		t.Errorf("MigrateUp = %v, %v, want an error at statement 2 of 001_init.up.sql", versions, err)
+	}
+
+	if last := f.log[len(f.log)-1]; last != "ROLLBACK" {
+		t.Errorf("last executed %q, want ROLLBACK", last)
+	}
+}
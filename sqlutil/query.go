@@ -0,0 +1,198 @@
+package sqlutil
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type BindType int
+
+const (
+	BindQuestion BindType = iota
+	BindDollar
+	BindAt
+)
+
+// Query 在运行时拼接SQL语句与参数，用于包含可选条件的查询。
+// Write 与 Where 的SQL文本统一使用?作为参数占位符，写入时按照BindType转换。
+type Query struct {
+	bindType      BindType
+	buffer        strings.Builder
+	args          []interface{}
+	conditions    []string
+	conditionArgs []interface{}
+}
+
+func NewQuery(bindType BindType) *Query {
+	return &Query{bindType: bindType}
+}
+
+func (q *Query) Write(text string, args ...interface{}) {
+	var quote byte
+	argIndex := 0
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '?' && argIndex < len(args):
+			q.writeArg(args[argIndex])
+			argIndex++
+			continue
+		}
+
+		q.buffer.WriteByte(c)
+	}
+}
+
+func (q *Query) writeArg(arg interface{}) {
+	if compare, ok := arg.(CompareArg); ok {
+		q.writeCompare(compare)
+		return
+	}
+
+	list, ok := arg.(ListArg)
+
+	if !ok {
+		q.args = append(q.args, arg)
+		q.buffer.WriteString(q.placeholder(len(q.args)))
+		return
+	}
+
+	if len(list) == 0 {
+		q.buffer.WriteString("NULL")
+		return
+	}
+
+	for i, v := range list {
+		if i > 0 {
+			q.buffer.WriteString(", ")
+		}
+
+		q.args = append(q.args, v)
+		q.buffer.WriteString(q.placeholder(len(q.args)))
+	}
+}
+
+func (q *Query) Where(condition string, args ...interface{}) {
+	q.conditions = append(q.conditions, condition)
+	q.conditionArgs = append(q.conditionArgs, args...)
+}
+
+func (q *Query) EndWhere() {
+	if len(q.conditions) > 0 {
+		q.Write("WHERE "+strings.Join(q.conditions, " AND ")+"\n", q.conditionArgs...)
+	}
+
+	q.conditions = nil
+	q.conditionArgs = nil
+}
+
+func (q *Query) String() string {
+	return q.buffer.String()
+}
+
+func (q *Query) Args() []interface{} {
+	return q.args
+}
+
+func (q *Query) placeholder(index int) string {
+	switch q.bindType {
+	case BindDollar:
+		return "$" + strconv.Itoa(index)
+	case BindAt:
+		return "@p" + strconv.Itoa(index)
+	}
+
+	return "?"
+}
+
+// ListArg 列表参数，写入SQL语句时展开为与元素个数相同的占位符，用于IN条件
+type ListArg []interface{}
+
+func List(values interface{}) ListArg {
+	v := reflect.ValueOf(values)
+
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return ListArg{values}
+	}
+
+	list := make(ListArg, v.Len())
+
+	for i := range list {
+		list[i] = v.Index(i).Interface()
+	}
+
+	return list
+}
+
+// CompareArg 与可以为nil的参数比较，写入SQL语句时参数为NULL生成 IS NULL 或 IS NOT NULL，否则生成比较运算符与占位符
+type CompareArg struct {
+	Op    string
+	Value interface{}
+}
+
+// Compare op为=或<>
+func Compare(op string, value interface{}) CompareArg {
+	return CompareArg{Op: op, Value: value}
+}
+
+func (q *Query) writeCompare(compare CompareArg) {
+	if !IsNull(compare.Value) {
+		q.buffer.WriteString(compare.Op)
+		q.buffer.WriteString(" ")
+		q.writeArg(compare.Value)
+		return
+	}
+
+	if compare.Op == "=" {
+		q.buffer.WriteString("IS NULL")
+	} else {
+		q.buffer.WriteString("IS NOT NULL")
+	}
+}
+
+// IsNull 判断参数写入数据库时是否为NULL：nil、nil指针以及Value()返回nil的driver.Valuer，如Valid为false的sql.NullString
+func IsNull(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	if value := reflect.ValueOf(v); value.Kind() == reflect.Pointer && value.IsNil() {
+		return true
+	}
+
+	if valuer, ok := v.(driver.Valuer); ok {
+		result, err := valuer.Value()
+
+		return err == nil && result == nil
+	}
+
+	return false
+}
+
+// IsZero 判断参数是否为nil、零值或空列表，此时对应的可选条件不会出现在SQL语句中
+func IsZero(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	value := reflect.ValueOf(v)
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+
+	return value.IsZero()
+}
@@ -0,0 +1,112 @@
+package sqlutil
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestQueryPlaceholders(t *testing.T) {
+	tests := []struct {
+		bindType BindType
+		want     string
+	}{
+		{BindQuestion, "SELECT * FROM t\nWHERE a = ? AND b IN (?, ?) AND c = '?'\nLIMIT ?"},
+		{BindDollar, "SELECT * FROM t\nWHERE a = $1 AND b IN ($2, $3) AND c = '?'\nLIMIT $4"},
+		{BindAt, "SELECT * FROM t\nWHERE a = @p1 AND b IN (@p2, @p3) AND c = '?'\nLIMIT @p4"},
+	}
+
+	for _, test := range tests {
+		q := NewQuery(test.bindType)
+		q.Write("SELECT * FROM t\n")
+		q.Where("a = ?", 1)
+		q.Where("b IN (?)", List([]string{"x", "y"}))
+		q.Where("c = '?'")
+		q.EndWhere()
+		q.Write("LIMIT ?", 10)
+
+		if q.String() != test.want {
+			t.Errorf("bind type %d: query %q, want %q", test.bindType, q.String(), test.want)
+		}
+
+		if want := []interface{}{1, "x", "y", 10}; !reflect.DeepEqual(q.Args(), want) {
+			t.Errorf("bind type %d: args %v, want %v", test.bindType, q.Args(), want)
+		}
+	}
+}
+
+func TestQueryWithoutConditions(t *testing.T) {
+	q := NewQuery(BindDollar)
+	q.Write("SELECT * FROM t\n")
+	q.EndWhere()
+	q.Write("ORDER BY [a?]")
+
+	if want := "SELECT * FROM t\nORDER BY [a?]"; q.String() != want || len(q.Args()) != 0 {
+		t.Errorf("query %q with args %v, want %q without args", q.String(), q.Args(), want)
+	}
+}
+
+func TestEmptyList(t *testing.T) {
+	q := NewQuery(BindQuestion)
+	q.Write("SELECT * FROM t WHERE a IN (?)", List([]int{}))
+
+	if want := "SELECT * FROM t WHERE a IN (NULL)"; q.String() != want || len(q.Args()) != 0 {
+		t.Errorf("query %q with args %v, want %q without args", q.String(), q.Args(), want)
+	}
+
+	if list := List(3); !reflect.DeepEqual(list, ListArg{3}) {
+		t.Errorf("List(3) = %v, want [3]", list)
+	}
+}
+
+func TestIsZero(t *testing.T) {
+	var nilPtr *int
+
+	tests := []struct {
+		value interface{}
+		want  bool
+	}{
+		{nil, true},
+		{0, true},
+		{"", true},
+		{[]int{}, true},
+		{nilPtr, true},
+		{1, false},
+		{"a", false},
+		{[]int{1}, false},
+	}
+
+	for _, test := range tests {
+		if got := IsZero(test.value); got != test.want {
+			t.Errorf("IsZero(%#v) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	var nilName *string
+	name := "a"
+
+	tests := []struct {
+		op    string
+		value interface{}
+		want  string
+		args  int
+	}{
+		{"=", nil, "a IS NULL", 0},
+		{"=", nilName, "a IS NULL", 0},
+		{"=", sql.NullString{}, "a IS NULL", 0},
+		{"<>", nil, "a IS NOT NULL", 0},
+		{"=", &name, "a = $1", 1},
+		{"<>", sql.NullString{String: "a", Valid: true}, "a <> $1", 1},
+	}
+
+	for _, test := range tests {
+		q := NewQuery(BindDollar)
+		q.Write("a ?", Compare(test.op, test.value))
+
+		if q.String() != test.want || len(q.Args()) != test.args {
+			t.Errorf("Compare(%q, %#v): %q with %d args, want %q with %d args", test.op, test.value, q.String(), len(q.Args()), test.want, test.args)
+		}
+	}
+}
@@ -3,6 +3,11 @@ package sqlutil
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
 )
 
 type DbObject interface {
@@ -10,63 +15,84 @@ type DbObject interface {
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
+// DataReadFunction 读取当前行，返回error时停止读取并返回该错误
 type DataReadFunction func(*sql.Rows) interface{}
 
-type DataChannel struct {
-	rows         *sql.Rows
-	cancel       context.CancelFunc
-	ctx          context.Context
-	readFunction DataReadFunction
-	channel      chan interface{}
+type Page[T any] struct {
+	Items []T
+	Total int64
+	Page  int
+	Size  int
 }
 
-func newDataChannel(rows *sql.Rows, readFunction DataReadFunction) *DataChannel {
-	c := &DataChannel{
-		rows:         rows,
-		readFunction: readFunction,
+func NewPage[T any](page int, size int) *Page[T] {
+	if page < 1 {
+		page = 1
 	}
 
-	c.ctx, c.cancel = context.WithCancel(context.Background())
-	c.channel = make(chan interface{})
+	if size < 1 {
+		size = 1
+	}
 
-	go func(c *DataChannel) {
-		defer close(c.channel)
-		defer c.rows.Close()
+	return &Page[T]{Page: page, Size: size}
+}
 
-		for c.rows.Next() {
-			data := c.readFunction(c.rows)
+// Offset 返回当前页第一条记录的偏移量，Page小于1时视为第一页
+func (p *Page[T]) Offset() int {
+	if p.Page < 1 {
+		return 0
+	}
 
-			select {
-			case <-c.ctx.Done():
-				return
-			case c.channel <- data:
-			}
-		}
-	}(c)
+	return (p.Page - 1) * p.Size
+}
 
-	return c
+// PageCount 返回总页数，Size不大于0时（如未使用NewPage创建的Page）返回0
+func (p *Page[T]) PageCount() int {
+	if p.Size <= 0 {
+		return 0
+	}
+
+	return int((p.Total + int64(p.Size) - 1) / int64(p.Size))
 }
 
-func (c *DataChannel) Get() <-chan interface{} {
-	return c.channel
+type DataChannel struct {
+	*Stream[interface{}]
 }
 
-func (c *DataChannel) Close() {
-	c.cancel()
+func toScanFunction(readFunction DataReadFunction) func(*sql.Rows) (interface{}, error) {
+	return func(rows *sql.Rows) (interface{}, error) {
+		data := readFunction(rows)
+
+		if err, ok := data.(error); ok {
+			return nil, err
+		}
+
+		return data, nil
+	}
 }
 
-func QueryChannel(e DbObject, query string, readFunc DataReadFunction, args ...interface{}) (*DataChannel, error) {
-	rows, err := e.QueryContext(context.Background(), query, args...)
+// QueryOne 执行查询并使用scan读取第一行，没有记录时返回T的零值与rows.Err()
+func QueryOne[T any](ctx context.Context, e DbObject, query string, scan func(*sql.Rows) (T, error), args ...interface{}) (T, error) {
+	var result T
+
+	rows, err := e.QueryContext(ctx, query, args...)
 
 	if err != nil {
-		return nil, err
+		return result, err
 	}
 
-	return newDataChannel(rows, readFunc), nil
+	defer rows.Close()
+
+	if rows.Next() {
+		return scan(rows)
+	}
+
+	return result, rows.Err()
 }
 
-func QueryRecord(e DbObject, query string, readFunc DataReadFunction, args ...interface{}) (interface{}, error) {
-	rows, err := e.QueryContext(context.Background(), query, args...)
+// QueryAll 执行查询并使用scan读取所有行，scan或rows.Err()的错误会中断读取并返回
+func QueryAll[T any](ctx context.Context, e DbObject, query string, scan func(*sql.Rows) (T, error), args ...interface{}) ([]T, error) {
+	rows, err := e.QueryContext(ctx, query, args...)
 
 	if err != nil {
 		return nil, err
@@ -74,17 +100,79 @@ func QueryRecord(e DbObject, query string, readFunc DataReadFunction, args ...in
 
 	defer rows.Close()
 
-	var result interface{}
+	var result []T
 
-	if rows.Next() {
-		result = readFunc(rows)
+	for rows.Next() {
+		data, err := scan(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, data)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
 
-func QueryRecordSet(e DbObject, query string, readFunc DataReadFunction, args ...interface{}) ([]interface{}, error) {
-	rows, err := e.QueryContext(context.Background(), query, args...)
+// QueryStream 执行查询并返回逐条读取结果的Stream，bufferSize为通道的缓冲区大小
+func QueryStream[T any](ctx context.Context, e DbObject, query string, bufferSize int, scan func(*sql.Rows) (T, error), args ...interface{}) (*Stream[T], error) {
+	rows, err := e.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStream(ctx, rows, bufferSize, scan), nil
+}
+
+// QueryIter 返回逐条读取结果的迭代器，开始遍历时才执行查询，不启动goroutine；
+// 查询、scan或rows.Err()的错误作为迭代的最后一个元素返回，遍历结束或提前退出时关闭rows
+func QueryIter[T any](ctx context.Context, e DbObject, query string, scan func(*sql.Rows) (T, error), args ...interface{}) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		rows, err := e.QueryContext(ctx, query, args...)
+
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			data, err := scan(rows)
+
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			if !yield(data, nil) {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// Exec 执行不返回记录的语句
+func Exec(ctx context.Context, e DbObject, query string, args ...interface{}) (sql.Result, error) {
+	return e.ExecContext(ctx, query, args...)
+}
+
+// ExecReturning 执行 INSERT ... RETURNING 或 OUTPUT INSERTED 语句，将返回的自增列读取到dest，
+// 返回的sql.Result中LastInsertId为dest的值
+func ExecReturning(ctx context.Context, e DbObject, query string, dest interface{}, args ...interface{}) (sql.Result, error) {
+	rows, err := e.QueryContext(ctx, query, args...)
 
 	if err != nil {
 		return nil, err
@@ -92,11 +180,209 @@ func QueryRecordSet(e DbObject, query string, readFunc DataReadFunction, args ..
 
 	defer rows.Close()
 
-	result := make([]interface{}, 0)
+	result := &returningResult{dest: dest}
 
 	for rows.Next() {
-		result = append(result, readFunc(rows))
+		if err := rows.Scan(dest); err != nil {
+			return nil, err
+		}
+
+		result.rowsAffected++
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ExecLastInsertId 执行语句并将LastInsertId写入dest，dest为整数或整数指针的指针，或者实现了sql.Scanner
+func ExecLastInsertId(ctx context.Context, e DbObject, query string, dest interface{}, args ...interface{}) (sql.Result, error) {
+	result, err := e.ExecContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setInt64(dest, id); err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
+
+type returningResult struct {
+	dest         interface{}
+	rowsAffected int64
+}
+
+func (r *returningResult) LastInsertId() (int64, error) {
+	return getInt64(r.dest)
+}
+
+func (r *returningResult) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+func setInt64(dest interface{}, value int64) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(value)
+	}
+
+	v := reflect.ValueOf(dest)
+
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("sqlutil: cannot set id to %T", dest)
+	}
+
+	v = v.Elem()
+
+	if v.Kind() == reflect.Ptr {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(value))
+	default:
+		return fmt.Errorf("sqlutil: cannot set id to %T", dest)
+	}
+
+	return nil
+}
+
+func getInt64(src interface{}) (int64, error) {
+	if valuer, ok := src.(driver.Valuer); ok {
+		value, err := valuer.Value()
+
+		if err != nil {
+			return 0, err
+		}
+
+		src = value
+	}
+
+	v := reflect.ValueOf(src)
+
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	}
+
+	return 0, fmt.Errorf("sqlutil: LastInsertId is not supported for %T", src)
+}
+
+// Results 多条语句的执行结果，按执行顺序保存每条语句的sql.Result
+type Results []sql.Result
+
+// Add 将一条语句的执行结果追加到r，err不为空时直接返回err，可以直接传入Exec的返回值：
+// results.Add(sqlutil.Exec(ctx, db, query, args...))
+func (r *Results) Add(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	*r = append(*r, result)
+
+	return nil
+}
+
+// LastInsertId 返回最后一条语句的LastInsertId
+func (r Results) LastInsertId() (int64, error) {
+	if len(r) == 0 {
+		return 0, errors.New("sqlutil: no statement executed")
+	}
+
+	return r[len(r)-1].LastInsertId()
+}
+
+// RowsAffected 返回所有语句影响的行数之和
+func (r Results) RowsAffected() (int64, error) {
+	var total int64
+
+	for _, result := range r {
+		n, err := result.RowsAffected()
+
+		if err != nil {
+			return 0, err
+		}
+
+		total += n
+	}
+
+	return total, nil
+}
+
+func QueryChannel(e DbObject, query string, readFunc DataReadFunction, args ...interface{}) (*DataChannel, error) {
+	return QueryChannelContext(context.Background(), e, query, readFunc, args...)
+}
+
+func QueryChannelContext(ctx context.Context, e DbObject, query string, readFunc DataReadFunction, args ...interface{}) (*DataChannel, error) {
+	stream, err := QueryStream(ctx, e, query, 0, toScanFunction(readFunc), args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &DataChannel{stream}, nil
+}
+
+func QueryRecord(e DbObject, query string, readFunc DataReadFunction, args ...interface{}) (interface{}, error) {
+	return QueryRecordContext(context.Background(), e, query, readFunc, args...)
+}
+
+func QueryRecordContext(ctx context.Context, e DbObject, query string, readFunc DataReadFunction, args ...interface{}) (interface{}, error) {
+	return QueryOne(ctx, e, query, toScanFunction(readFunc), args...)
+}
+
+func QueryRecordSet(e DbObject, query string, readFunc DataReadFunction, args ...interface{}) ([]interface{}, error) {
+	return QueryRecordSetContext(context.Background(), e, query, readFunc, args...)
+}
+
+func QueryRecordSetContext(ctx context.Context, e DbObject, query string, readFunc DataReadFunction, args ...interface{}) ([]interface{}, error) {
+	result, err := QueryAll(ctx, e, query, toScanFunction(readFunc), args...)
+
+	if result == nil && err == nil {
+		result = make([]interface{}, 0)
+	}
+
+	return result, err
+}
+
+// ScanNullable 返回先扫描到sql.Null[T]再赋值给dest的sql.Scanner，NULL转换为零值，
+// 用于标记了null:"true"的非指针字段以及外连接中可能没有匹配记录的列
+func ScanNullable[T any](dest *T) sql.Scanner {
+	return nullableScanner[T]{dest}
+}
+
+type nullableScanner[T any] struct {
+	dest *T
+}
+
+func (s nullableScanner[T]) Scan(src interface{}) error {
+	var value sql.Null[T]
+
+	if err := value.Scan(src); err != nil {
+		return err
+	}
+
+	*s.dest = value.V
+
+	return nil
+}
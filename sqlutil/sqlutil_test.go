@@ -0,0 +1,278 @@
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestPage(t *testing.T) {
+	tests := []struct {
+		page      Page[int]
+		offset    int
+		pageCount int
+	}{
+		{Page[int]{}, 0, 0},
+		{Page[int]{Total: 10}, 0, 0},
+		{Page[int]{Total: 10, Page: 1, Size: -1}, 0, 0},
+		{Page[int]{Total: 0, Page: 1, Size: 10}, 0, 0},
+		{Page[int]{Total: 10, Page: 1, Size: 10}, 0, 1},
+		{Page[int]{Total: 11, Page: 2, Size: 10}, 10, 2},
+		{Page[int]{Total: 25, Page: 3, Size: 5}, 10, 5},
+	}
+
+	for _, test := range tests {
+		p := test.page
+
+		if offset := p.Offset(); offset != test.offset {
+			t.Errorf("%+v: Offset() = %d, want %d", p, offset, test.offset)
+		}
+
+		if count := p.PageCount(); count != test.pageCount {
+			t.Errorf("%+v: PageCount() = %d, want %d", p, count, test.pageCount)
+		}
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	p := NewPage[int](0, 0)
+
+	if p.Page != 1 || p.Size != 1 {
+		t.Errorf("NewPage(0, 0) = %+v, want Page 1 and Size 1", p)
+	}
+
+	if p := NewPage[int](3, 20); p.Offset() != 40 {
+		t.Errorf("NewPage(3, 20).Offset() = %d, want 40", p.Offset())
+	}
+}
+
+func TestScanNullable(t *testing.T) {
+	var (
+		id   int
+		name string
+		rate float64
+	)
+
+	if err := ScanNullable(&id).Scan(int64(3)); err != nil || id != 3 {
+		t.Errorf("scan int64 into int: %d, %v", id, err)
+	}
+
+	if err := ScanNullable(&id).Scan(nil); err != nil || id != 0 {
+		t.Errorf("scan NULL into int: %d, %v, want zero value", id, err)
+	}
+
+	if err := ScanNullable(&name).Scan([]byte("a")); err != nil || name != "a" {
+		t.Errorf("scan []byte into string: %q, %v", name, err)
+	}
+
+	if err := ScanNullable(&name).Scan(nil); err != nil || name != "" {
+		t.Errorf("scan NULL into string: %q, %v, want zero value", name, err)
+	}
+
+	if err := ScanNullable(&rate).Scan("1.5"); err != nil || rate != 1.5 {
+		t.Errorf("scan string into float64: %v, %v", rate, err)
+	}
+
+	if err := ScanNullable(&id).Scan("x"); err == nil {
+		t.Errorf("scan \"x\" into int succeeded, want an error")
+	}
+}
+
+type testResult struct{ id, rows int64 }
+
+func (r testResult) LastInsertId() (int64, error) { return r.id, nil }
+func (r testResult) RowsAffected() (int64, error) { return r.rows, nil }
+
+func TestResults(t *testing.T) {
+	var results Results
+
+	if _, err := results.LastInsertId(); err == nil {
+		t.Errorf("LastInsertId of empty Results succeeded, want an error")
+	}
+
+	failed := errors.New("failed")
+
+	if err := results.Add(testResult{1, 2}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := results.Add(nil, failed); err != failed || len(results) != 1 {
+		t.Errorf("Add with error: %v and %d results, want %v and 1 result", err, len(results), failed)
+	}
+
+	results.Add(testResult{5, 3}, nil)
+
+	if id, err := results.LastInsertId(); err != nil || id != 5 {
+		t.Errorf("LastInsertId() = %d, %v, want 5", id, err)
+	}
+
+	if n, err := results.RowsAffected(); err != nil || n != 5 {
+		t.Errorf("RowsAffected() = %d, %v, want 5", n, err)
+	}
+}
+
+func TestExecReturning(t *testing.T) {
+	db, f := newFakeDB(t)
+	f.rows = [][]driver.Value{{int64(7)}, {int64(8)}}
+
+	var id int64
+
+	result, err := ExecReturning(context.Background(), db, "INSERT RETURNING", &id)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id != 8 {
+		t.Errorf("dest = %d, want 8", id)
+	}
+
+	if lastID, err := result.LastInsertId(); err != nil || lastID != 8 {
+		t.Errorf("LastInsertId() = %d, %v, want 8", lastID, err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil || n != 2 {
+		t.Errorf("RowsAffected() = %d, %v, want 2", n, err)
+	}
+}
+
+func TestExecLastInsertId(t *testing.T) {
+	db, f := newFakeDB(t)
+	f.lastInsertID = 42
+
+	var (
+		id32     int32
+		idPtr    *int64
+		nullID   sql.NullInt64
+		stringID string
+	)
+
+	for _, dest := range []interface{}{&id32, &idPtr, &nullID} {
+		if _, err := ExecLastInsertId(context.Background(), db, "INSERT", dest); err != nil {
+			t.Errorf("ExecLastInsertId into %T: %v", dest, err)
+		}
+	}
+
+	if id32 != 42 || idPtr == nil || *idPtr != 42 || nullID != (sql.NullInt64{Int64: 42, Valid: true}) {
+		t.Errorf("ids %d, %v, %v, want 42", id32, idPtr, nullID)
+	}
+
+	if _, err := ExecLastInsertId(context.Background(), db, "INSERT", &stringID); err == nil {
+		t.Errorf("ExecLastInsertId into *string succeeded, want an error")
+	}
+}
+
+func scanInt(rows *sql.Rows) (int, error) {
+	var n int
+	return n, rows.Scan(&n)
+}
+
+func TestQueryOneAndAll(t *testing.T) {
+	db, f := newFakeDB(t)
+	ctx := context.Background()
+
+	if n, err := QueryOne(ctx, db, "SELECT", scanInt); err != nil || n != 0 {
+		t.Errorf("QueryOne without rows = %d, %v, want 0", n, err)
+	}
+
+	f.rows = [][]driver.Value{{int64(1)}, {int64(2)}}
+
+	if n, err := QueryOne(ctx, db, "SELECT", scanInt); err != nil || n != 1 {
+		t.Errorf("QueryOne = %d, %v, want 1", n, err)
+	}
+
+	if !f.lastRows.closed {
+		t.Errorf("QueryOne did not close rows")
+	}
+
+	if all, err := QueryAll(ctx, db, "SELECT", scanInt); err != nil || len(all) != 2 || all[0] != 1 || all[1] != 2 {
+		t.Errorf("QueryAll = %v, %v, want [1 2]", all, err)
+	}
+}
+
+func TestQueryIter(t *testing.T) {
+	db, f := newFakeDB(t)
+	f.rows = [][]driver.Value{{int64(1)}, {int64(2)}, {int64(3)}}
+
+	seq := QueryIter(context.Background(), db, "SELECT", scanInt)
+
+	if f.lastRows != nil {
+		t.Fatalf("QueryIter executed the query before iteration")
+	}
+
+	var got []int
+
+	for n, err := range seq {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got = append(got, n)
+
+		if n == 2 {
+			break
+		}
+	}
+
+	if len(got) != 2 || !f.lastRows.closed {
+		t.Errorf("break after %v: rows closed %v, want [1 2] and closed rows", got, f.lastRows.closed)
+	}
+
+	f.rows = [][]driver.Value{{"x"}}
+
+	for _, err := range seq {
+		if err == nil {
+			t.Errorf("scan \"x\" into int succeeded, want an error")
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	ctx := context.Background()
+	db, f := newFakeDB(t)
+	rowsErr := errors.New("connection reset")
+
+	// rows.Scan的错误
+	f.rows = [][]driver.Value{{int64(1)}, {"x"}}
+
+	if all, err := QueryAll(ctx, db, "SELECT", scanInt); err == nil || all != nil {
+		t.Errorf("QueryAll with a scan error = %v, %v, want an error", all, err)
+	}
+
+	f.rows = [][]driver.Value{{"x"}}
+
+	if _, err := QueryOne(ctx, db, "SELECT", scanInt); err == nil {
+		t.Error("QueryOne with a scan error succeeded")
+	}
+
+	// 读取记录中断时rows.Err()的错误
+	f.rows = [][]driver.Value{{int64(1)}}
+	f.rowsErr = rowsErr
+
+	if all, err := QueryAll(ctx, db, "SELECT", scanInt); err != rowsErr || all != nil {
+		t.Errorf("QueryAll = %v, %v, want %v", all, err, rowsErr)
+	}
+
+	f.rows = nil
+
+	if _, err := QueryOne(ctx, db, "SELECT", scanInt); err != rowsErr {
+		t.Errorf("QueryOne without rows = %v, want %v", err, rowsErr)
+	}
+
+	f.rows = [][]driver.Value{{int64(1)}}
+
+	stream, err := QueryStream(ctx, db, "SELECT", 0, scanInt)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range stream.Get() {
+	}
+
+	if stream.Err() != rowsErr {
+		t.Errorf("Stream.Err() = %v, want %v", stream.Err(), rowsErr)
+	}
+}
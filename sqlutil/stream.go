@@ -0,0 +1,69 @@
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// Stream 以通道的方式逐条读取查询结果，通道关闭后通过Err获取读取过程中发生的错误
+type Stream[T any] struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	channel chan T
+	mutex   sync.Mutex
+	err     error
+}
+
+func NewStream[T any](ctx context.Context, rows *sql.Rows, bufferSize int, scan func(*sql.Rows) (T, error)) *Stream[T] {
+	s := &Stream[T]{channel: make(chan T, bufferSize)}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	go func() {
+		defer close(s.channel)
+		defer rows.Close()
+
+		for rows.Next() {
+			data, err := scan(rows)
+
+			if err != nil {
+				s.setErr(err)
+				return
+			}
+
+			select {
+			case <-s.ctx.Done():
+				s.setErr(ctx.Err())
+				return
+			case s.channel <- data:
+			}
+		}
+
+		s.setErr(rows.Err())
+	}()
+
+	return s
+}
+
+func (s *Stream[T]) setErr(err error) {
+	s.mutex.Lock()
+	s.err = err
+	s.mutex.Unlock()
+}
+
+func (s *Stream[T]) Get() <-chan T {
+	return s.channel
+}
+
+// Close 停止读取，通道随后关闭
+func (s *Stream[T]) Close() {
+	s.cancel()
+}
+
+// Err 返回读取中断的原因，正常读取完毕或调用Close停止时返回nil
+func (s *Stream[T]) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.err
+}
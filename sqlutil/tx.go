@@ -0,0 +1,197 @@
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TxOptions WithTx的选项，MaxRetries大于0时，事务因序列化失败或死锁失败后重新执行fn
+type TxOptions struct {
+	Isolation  sql.IsolationLevel
+	ReadOnly   bool
+	MaxRetries int
+	// IsRetryable 判断错误是否可以重试，为空时使用IsSerializationFailure
+	IsRetryable func(error) bool
+	// BindType 为BindAt时使用SQL Server的保存点语法。db为*sql.DB时从驱动判断，为*sql.Conn时从底层连接判断，
+	// db为*sql.Tx时无法得到驱动，嵌套在SQL Server的*sql.Tx中需要指定BindAt
+	BindType BindType
+}
+
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// txObject WithTx传给fn的事务，depth为保存点的嵌套深度
+type txObject struct {
+	*sql.Tx
+	depth     int
+	sqlServer bool
+}
+
+// WithTx 在事务中执行fn，fn返回错误或panic时回滚，否则提交。
+// db为*sql.DB、*sql.Conn时开始新的事务；db为WithTx传给fn的事务或*sql.Tx时使用保存点，
+// fn失败只回滚到保存点，由外层事务决定提交或回滚，此时只使用opts.BindType。
+// opts为空时使用默认的隔离级别且不重试
+func WithTx(ctx context.Context, db DbObject, opts *TxOptions, fn func(tx DbObject) error) error {
+	switch inst := db.(type) {
+	case *txObject:
+		return withSavepoint(ctx, inst, fn)
+	case *sql.Tx:
+		return withSavepoint(ctx, &txObject{Tx: inst, sqlServer: opts != nil && opts.BindType == BindAt}, fn)
+	}
+
+	beginner, ok := db.(txBeginner)
+
+	if !ok {
+		return fmt.Errorf("sqlutil: %T cannot begin a transaction", db)
+	}
+
+	var txOpts *sql.TxOptions
+	var maxRetries int
+	isRetryable := IsSerializationFailure
+
+	if opts != nil {
+		txOpts = &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly}
+		maxRetries = opts.MaxRetries
+
+		if opts.IsRetryable != nil {
+			isRetryable = opts.IsRetryable
+		}
+	}
+
+	sqlServer := isSQLServer(db) || (opts != nil && opts.BindType == BindAt)
+
+	for retry := 0; ; retry++ {
+		err := runTx(ctx, beginner, txOpts, sqlServer, fn)
+
+		if err == nil || retry >= maxRetries || ctx.Err() != nil || !isRetryable(err) {
+			return err
+		}
+	}
+}
+
+func runTx(ctx context.Context, beginner txBeginner, opts *sql.TxOptions, sqlServer bool, fn func(tx DbObject) error) error {
+	tx, err := beginner.BeginTx(ctx, opts)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&txObject{Tx: tx, sqlServer: sqlServer}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func withSavepoint(ctx context.Context, parent *txObject, fn func(tx DbObject) error) error {
+	tx := &txObject{Tx: parent.Tx, depth: parent.depth + 1, sqlServer: parent.sqlServer}
+	name := "sqlutil_sp" + strconv.Itoa(tx.depth)
+
+	save := "SAVEPOINT " + name
+	rollback := "ROLLBACK TO SAVEPOINT " + name
+	release := "RELEASE SAVEPOINT " + name
+
+	// sqlserver没有释放保存点的语句
+	if tx.sqlServer {
+		save = "SAVE TRANSACTION " + name
+		rollback = "ROLLBACK TRANSACTION " + name
+		release = ""
+	}
+
+	if _, err := tx.ExecContext(ctx, save); err != nil {
+		return err
+	}
+
+	// panic时由最外层的WithTx回滚整个事务
+	if err := fn(tx); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, rollback); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+
+		return err
+	}
+
+	if release != "" {
+		if _, err := tx.ExecContext(ctx, release); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isSQLServer 从*sql.DB的驱动或*sql.Conn的底层连接判断是否为SQL Server
+func isSQLServer(db DbObject) bool {
+	switch inst := db.(type) {
+	case *sql.DB:
+		return isSQLServerDriverType(inst.Driver())
+	case *sql.Conn:
+		var result bool
+
+		inst.Raw(func(driverConn interface{}) error {
+			result = isSQLServerDriverType(driverConn)
+			return nil
+		})
+
+		return result
+	}
+
+	return false
+}
+
+func isSQLServerDriverType(v interface{}) bool {
+	t := reflect.TypeOf(v)
+
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t != nil && strings.Contains(t.PkgPath(), "mssql")
+}
+
+// IsSerializationFailure 判断err是否为可以重新执行事务的序列化失败或死锁：
+// SQLSTATE 40001、40P01，sqlserver的1205，mysql的1213、1205，sqlite的SQLITE_BUSY、SQLITE_LOCKED
+func IsSerializationFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var state interface{ SQLState() string }
+
+	if errors.As(err, &state) {
+		code := state.SQLState()
+		return code == "40001" || code == "40P01"
+	}
+
+	var number interface{ SQLErrorNumber() int32 }
+
+	if errors.As(err, &number) {
+		return number.SQLErrorNumber() == 1205
+	}
+
+	var code interface{ Code() int }
+
+	if errors.As(err, &code) {
+		primary := code.Code() & 0xff
+		return primary == 5 || primary == 6
+	}
+
+	// mysql驱动的错误没有返回错误号的方法，错误信息以"Error 错误号"开头
+	message := err.Error()
+
+	return strings.HasPrefix(message, "Error 1213") || strings.HasPrefix(message, "Error 1205")
+}
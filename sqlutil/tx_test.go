@@ -0,0 +1,214 @@
+package sqlutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func execTest(ctx context.Context, query string) func(tx DbObject) error {
+	return func(tx DbObject) error {
+		_, err := tx.ExecContext(ctx, query)
+		return err
+	}
+}
+
+func checkLog(t *testing.T, f *fakeDB, want ...string) {
+	t.Helper()
+
+	if !reflect.DeepEqual(f.log, want) {
+		t.Errorf("executed %q, want %q", f.log, want)
+	}
+
+	f.log = nil
+}
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	db, f := newFakeDB(t)
+	fail := errors.New("fail")
+
+	if err := WithTx(ctx, db, nil, execTest(ctx, "A")); err != nil {
+		t.Fatal(err)
+	}
+
+	checkLog(t, f, "BEGIN", "A", "COMMIT")
+
+	err := WithTx(ctx, db, nil, func(tx DbObject) error { return fail })
+
+	if err != fail {
+		t.Errorf("WithTx = %v, want %v", err, fail)
+	}
+
+	checkLog(t, f, "BEGIN", "ROLLBACK")
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recover() = %v, want boom", p)
+			}
+		}()
+
+		WithTx(ctx, db, nil, func(tx DbObject) error { panic("boom") })
+	}()
+
+	checkLog(t, f, "BEGIN", "ROLLBACK")
+}
+
+func TestWithTxSavepoint(t *testing.T) {
+	ctx := context.Background()
+	db, f := newFakeDB(t)
+
+	err := WithTx(ctx, db, nil, func(tx DbObject) error {
+		if err := WithTx(ctx, tx, nil, execTest(ctx, "A")); err != nil {
+			return err
+		}
+
+		if err := WithTx(ctx, tx, nil, func(tx DbObject) error { return errors.New("fail") }); err == nil {
+			return errors.New("inner transaction succeeded")
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkLog(t, f, "BEGIN",
+		"SAVEPOINT sqlutil_sp1", "A", "RELEASE SAVEPOINT sqlutil_sp1",
+		"SAVEPOINT sqlutil_sp1", "ROLLBACK TO SAVEPOINT sqlutil_sp1",
+		"COMMIT")
+}
+
+func TestWithTxRawTx(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		opts *TxOptions
+		want []string
+	}{
+		{nil, []string{"BEGIN", "SAVEPOINT sqlutil_sp1", "SAVEPOINT sqlutil_sp2", "ROLLBACK TO SAVEPOINT sqlutil_sp2",
+			"ROLLBACK TO SAVEPOINT sqlutil_sp1", "ROLLBACK"}},
+		{&TxOptions{BindType: BindAt}, []string{"BEGIN", "SAVE TRANSACTION sqlutil_sp1", "SAVE TRANSACTION sqlutil_sp2",
+			"ROLLBACK TRANSACTION sqlutil_sp2", "ROLLBACK TRANSACTION sqlutil_sp1", "ROLLBACK"}},
+	}
+
+	for _, test := range tests {
+		db, f := newFakeDB(t)
+		tx, err := db.Begin()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 内层的WithTx使用外层传入的事务，不需要再指定BindType
+		WithTx(ctx, tx, test.opts, func(tx DbObject) error {
+			return WithTx(ctx, tx, nil, func(tx DbObject) error { return errors.New("fail") })
+		})
+
+		tx.Rollback()
+
+		checkLog(t, f, test.want...)
+	}
+}
+
+func TestWithTxRetry(t *testing.T) {
+	ctx := context.Background()
+	db, f := newFakeDB(t)
+
+	f.failCommits = 1
+
+	if err := WithTx(ctx, db, &TxOptions{MaxRetries: 1}, execTest(ctx, "A")); err != nil {
+		t.Fatal(err)
+	}
+
+	checkLog(t, f, "BEGIN", "A", "COMMIT failed", "BEGIN", "A", "COMMIT")
+
+	f.failCommits = 2
+
+	if err := WithTx(ctx, db, &TxOptions{MaxRetries: 1}, execTest(ctx, "A")); !IsSerializationFailure(err) {
+		t.Errorf("WithTx = %v, want the serialization failure", err)
+	}
+
+	checkLog(t, f, "BEGIN", "A", "COMMIT failed", "BEGIN", "A", "COMMIT failed")
+
+	// 不可重试的错误直接返回
+	fail := errors.New("fail")
+	runs := 0
+
+	err := WithTx(ctx, db, &TxOptions{MaxRetries: 3}, func(tx DbObject) error {
+		runs++
+		return fail
+	})
+
+	if err != fail || runs != 1 {
+		t.Errorf("WithTx = %v after %d runs, want %v after 1 run", err, runs, fail)
+	}
+}
+
+type sqlServerError struct{ number int32 }
+
+func (e sqlServerError) Error() string         { return fmt.Sprintf("mssql: error %d", e.number) }
+func (e sqlServerError) SQLErrorNumber() int32 { return e.number }
+
+type sqliteError struct{ code int }
+
+func (e sqliteError) Error() string { return fmt.Sprintf("sqlite: error %d", e.code) }
+func (e sqliteError) Code() int     { return e.code }
+
+func TestIsSerializationFailure(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("fail"), false},
+		{serializationError{}, true},
+		{fmt.Errorf("commit: %w", serializationError{}), true},
+		{sqlServerError{1205}, true},
+		{sqlServerError{2627}, false},
+		{sqliteError{5}, true},
+		{sqliteError{6 | 1<<8}, true},
+		{sqliteError{19}, false},
+		{errors.New("Error 1213 (40001): Deadlock found"), true},
+		{errors.New("Error 1062: Duplicate entry"), false},
+	}
+
+	for _, test := range tests {
+		if got := IsSerializationFailure(test.err); got != test.want {
+			t.Errorf("IsSerializationFailure(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
+
+type mssqlDriverConn struct{}
+
+func TestWithTxConn(t *testing.T) {
+	ctx := context.Background()
+	db, f := newFakeDB(t)
+	conn, err := db.Conn(ctx)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	if isSQLServer(conn) {
+		t.Error("isSQLServer of a fake *sql.Conn is true")
+	}
+
+	// Raw之后连接仍然可以使用
+	if err := WithTx(ctx, conn, nil, execTest(ctx, "A")); err != nil {
+		t.Fatal(err)
+	}
+
+	checkLog(t, f, "BEGIN", "A", "COMMIT")
+
+	if isSQLServerDriverType(mssqlDriverConn{}) || isSQLServerDriverType(nil) {
+		t.Error("isSQLServerDriverType is true for a type outside of an mssql package")
+	}
+}